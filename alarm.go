@@ -0,0 +1,45 @@
+package ical
+
+import (
+	"time"
+)
+
+// TriggerTime computes when the alarm of the event goes off. The trigger
+// duration is relative to the start of the event, or to its end when the
+// TRIGGER has the RELATED=END param. For all-day events the anchor is the
+// date boundary, midnight of the start date or of the exclusive end date.
+// Days are counted on the wall clock of the anchor, a -P1DT12H trigger goes
+// off at noon the day before an event starting at midnight even across a
+// daylight saving time transition. An absolute trigger is returned as is,
+// regardless of the event.
+func (a *Alarm) TriggerTime(v *Event) (time.Time, error) {
+	if !a.TriggerDate.IsZero() {
+		return a.TriggerDate, nil
+	}
+
+	days, d, err := parseNominalDuration(a.Trigger)
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	anchor := v.StartDate
+
+	if prop := findProperty("TRIGGER", a.Properties); prop != nil {
+		if related, _ := prop.ParamValue("RELATED"); related == "END" {
+			anchor = v.EndDate
+		}
+	}
+
+	if v.AllDay {
+		anchor = time.Date(anchor.Year(), anchor.Month(), anchor.Day(), 0, 0, 0, 0, anchor.Location())
+	}
+
+	return anchor.AddDate(0, 0, days).Add(d), nil
+}
+
+// PropertyMap indexes the alarm properties by name. The map is built on
+// each call, callers doing many lookups should keep it around.
+func (a *Alarm) PropertyMap() map[string][]*Property {
+	return propertyMap(a.Properties)
+}
@@ -0,0 +1,156 @@
+package ical
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAlarm_TriggerTime(t *testing.T) {
+	timed := NewEvent()
+	timed.StartDate = time.Date(2020, time.February, 11, 9, 0, 0, 0, time.UTC)
+	timed.EndDate = time.Date(2020, time.February, 11, 10, 0, 0, 0, time.UTC)
+
+	allDay := NewEvent()
+	allDay.AllDay = true
+	allDay.StartDate = time.Date(2020, time.February, 11, 0, 0, 0, 0, time.UTC)
+	allDay.EndDate = time.Date(2020, time.February, 14, 0, 0, 0, 0, time.UTC)
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the day before crosses the daylight saving time transition of March 29
+	afterDST := NewEvent()
+	afterDST.StartDate = time.Date(2020, time.March, 30, 0, 0, 0, 0, paris)
+	afterDST.EndDate = time.Date(2020, time.March, 30, 1, 0, 0, 0, paris)
+
+	tests := []struct {
+		name    string
+		event   *Event
+		trigger string
+		related string
+		want    time.Time
+	}{
+		{
+			name:    "Relative to start",
+			event:   timed,
+			trigger: "-PT15M",
+			want:    time.Date(2020, time.February, 11, 8, 45, 0, 0, time.UTC),
+		},
+		{
+			name:    "Explicitly relative to start",
+			event:   timed,
+			trigger: "-PT15M",
+			related: "START",
+			want:    time.Date(2020, time.February, 11, 8, 45, 0, 0, time.UTC),
+		},
+		{
+			name:    "Relative to end",
+			event:   timed,
+			trigger: "PT5M",
+			related: "END",
+			want:    time.Date(2020, time.February, 11, 10, 5, 0, 0, time.UTC),
+		},
+		{
+			name:    "All-day relative to start",
+			event:   allDay,
+			trigger: "-PT15H",
+			want:    time.Date(2020, time.February, 10, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "All-day relative to end",
+			event:   allDay,
+			trigger: "-P1D",
+			related: "END",
+			want:    time.Date(2020, time.February, 13, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "Days and time",
+			event:   timed,
+			trigger: "-P1DT12H",
+			want:    time.Date(2020, time.February, 9, 21, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "Week in days",
+			event:   timed,
+			trigger: "-P7D",
+			want:    time.Date(2020, time.February, 4, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "Week in minutes",
+			event:   timed,
+			trigger: "-PT10080M",
+			want:    time.Date(2020, time.February, 4, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "Days across daylight saving time",
+			event:   afterDST,
+			trigger: "-P1DT12H",
+			want:    time.Date(2020, time.March, 28, 12, 0, 0, 0, paris),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAlarm()
+			a.Action = "DISPLAY"
+			a.Trigger = tt.trigger
+			prop := NewProperty()
+			prop.Name = "TRIGGER"
+			prop.Value = tt.trigger
+			if tt.related != "" {
+				prop.Params["RELATED"] = &Param{Values: []string{tt.related}}
+			}
+			a.Properties = append(a.Properties, prop)
+
+			got, err := a.TriggerTime(tt.event)
+			if err != nil {
+				t.Fatalf("TriggerTime() error = %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("TriggerTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlarm_TriggerTime_absolute(t *testing.T) {
+	file, _ := os.Open("fixtures/todo.ics")
+	c, err := Parse(file, nil)
+	file.Close()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := time.Date(1998, time.April, 3, 12, 0, 0, 0, time.UTC)
+	got, err := c.Todos[0].Alarms[0].TriggerTime(NewEvent())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !got.Equal(want) {
+		t.Errorf("TriggerTime() = %v, want %v", got, want)
+	}
+}
+
+func TestParse_absoluteTriggerWithRelated(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:19980130T134500Z\r\nUID:uid@example.com\r\nDTSTART:19980415T000000Z\r\n" +
+		"BEGIN:VALARM\r\nACTION:DISPLAY\r\nTRIGGER;VALUE=DATE-TIME;RELATED=END:19980403T120000Z\r\nEND:VALARM\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	if _, err := Parse(strings.NewReader(ics), nil); err == nil {
+		t.Error("expected an error when RELATED is set on an absolute trigger")
+	}
+
+	// the last VALUE wins, as for the dates
+	ics = strings.Replace(ics, "VALUE=DATE-TIME", "VALUE=DURATION;VALUE=DATE-TIME", 1)
+
+	if _, err := Parse(strings.NewReader(ics), nil); err == nil {
+		t.Error("expected an error when RELATED is set on a trigger whose last VALUE is DATE-TIME")
+	}
+}
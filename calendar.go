@@ -0,0 +1,527 @@
+package ical
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"time"
+)
+
+// SetProduct sets both required PRODID and VERSION properties
+func (c *Calendar) SetProduct(prodID, version string) {
+	c.Prodid = prodID
+	c.Version = version
+}
+
+// SetRefreshInterval sets how often subscribers should refresh the
+// calendar, with both the REFRESH-INTERVAL property of RFC 7986 and the
+// X-PUBLISHED-TTL property older clients read. A zero duration removes them.
+func (c *Calendar) SetRefreshInterval(d time.Duration) {
+	properties := make([]*Property, 0, len(c.Properties)+2)
+
+	for _, prop := range c.Properties {
+		if prop.Name != "REFRESH-INTERVAL" && prop.Name != "X-PUBLISHED-TTL" {
+			properties = append(properties, prop)
+		}
+	}
+
+	if d > 0 {
+		properties = append(properties,
+			&Property{
+				Name:   "REFRESH-INTERVAL",
+				Params: map[string]*Param{"VALUE": {Values: []string{string(ValueDuration)}}},
+				Value:  formatDuration(d),
+			},
+			&Property{Name: "X-PUBLISHED-TTL", Params: map[string]*Param{}, Value: formatDuration(d)},
+		)
+	}
+
+	c.Properties = properties
+}
+
+// AddEvent appends the event v to the calendar
+func (c *Calendar) AddEvent(v *Event) {
+	c.Events = append(c.Events, v)
+}
+
+// Walk calls fn for every property of the calendar and its components,
+// along with the name of the component holding it (VCALENDAR, VTIMEZONE,
+// STANDARD, DAYLIGHT, VEVENT, VTODO, VJOURNAL, VFREEBUSY, VALARM or the name of
+// a registered component). The calendar properties come first, then the
+// components in document order, each alarm or nested component right after
+// the properties of its parent. The components added after parsing follow,
+// grouped by type: timezones, events, todos, journals, free/busy and
+// registered components.
+func (c *Calendar) Walk(fn func(component string, prop *Property)) {
+	walkProperties("VCALENDAR", c.Properties, fn)
+
+	components := c.components()
+	present := make(map[interface{}]bool, len(components))
+
+	for _, comp := range components {
+		present[comp] = true
+	}
+
+	// the components removed since parsing are skipped
+	for _, comp := range c.order {
+		if present[comp] {
+			delete(present, comp)
+			walkComponent(comp, fn)
+		}
+	}
+
+	for _, comp := range components {
+		if present[comp] {
+			walkComponent(comp, fn)
+		}
+	}
+}
+
+// components lists the components of the calendar grouped by type
+func (c *Calendar) components() []interface{} {
+	components := make([]interface{}, 0, len(c.Timezones)+len(c.Events)+len(c.Todos)+len(c.Journals)+len(c.FreeBusys)+len(c.Components))
+
+	for _, z := range c.Timezones {
+		components = append(components, z)
+	}
+
+	for _, v := range c.Events {
+		components = append(components, v)
+	}
+
+	for _, t := range c.Todos {
+		components = append(components, t)
+	}
+
+	for _, j := range c.Journals {
+		components = append(components, j)
+	}
+
+	for _, fb := range c.FreeBusys {
+		components = append(components, fb)
+	}
+
+	for _, comp := range c.Components {
+		components = append(components, comp)
+	}
+
+	return components
+}
+
+// walkComponent calls fn for every property of a component of the calendar
+func walkComponent(comp interface{}, fn func(component string, prop *Property)) {
+	switch comp := comp.(type) {
+	case *Timezone:
+		walkProperties("VTIMEZONE", comp.Properties, fn)
+
+		for _, r := range comp.Standard {
+			walkProperties("STANDARD", r.Properties, fn)
+		}
+
+		for _, r := range comp.Daylight {
+			walkProperties("DAYLIGHT", r.Properties, fn)
+		}
+	case *Event:
+		walkProperties("VEVENT", comp.Properties, fn)
+		walkAlarms(comp.Alarms, fn)
+	case *Todo:
+		walkProperties("VTODO", comp.Properties, fn)
+		walkAlarms(comp.Alarms, fn)
+	case *Journal:
+		walkProperties("VJOURNAL", comp.Properties, fn)
+	case *FreeBusy:
+		walkProperties("VFREEBUSY", comp.Properties, fn)
+	case *Component:
+		walkComponents([]*Component{comp}, fn)
+	}
+}
+
+func walkComponents(components []*Component, fn func(component string, prop *Property)) {
+	for _, comp := range components {
+		walkProperties(comp.Name, comp.Properties, fn)
+		walkComponents(comp.Components, fn)
+	}
+}
+
+func walkAlarms(alarms []*Alarm, fn func(component string, prop *Property)) {
+	for _, a := range alarms {
+		walkProperties("VALARM", a.Properties, fn)
+	}
+}
+
+func walkProperties(component string, properties []*Property, fn func(component string, prop *Property)) {
+	for _, prop := range properties {
+		fn(component, prop)
+	}
+}
+
+// A ProductInfo holds the segments of a PRODID following the formal public
+// identifier convention, "-//vendor//product//language"
+type ProductInfo struct {
+	Vendor   string
+	Product  string // without the NONSGML marker
+	Language string
+}
+
+// ProductInfo splits the PRODID of the calendar into its vendor, product and
+// language. It never fails: a PRODID without the "-//" or "+//" prefix is
+// taken as a product name, and the missing segments are left empty.
+func (c *Calendar) ProductInfo() ProductInfo {
+	prodID := strings.TrimSpace(c.Prodid)
+	rest, ok := strings.CutPrefix(prodID, "-//")
+
+	if !ok {
+		rest, ok = strings.CutPrefix(prodID, "+//")
+	}
+
+	if !ok {
+		return ProductInfo{Product: prodID}
+	}
+
+	var info ProductInfo
+	segments := strings.Split(rest, "//")
+
+	info.Vendor = segments[0]
+
+	if len(segments) > 2 {
+		info.Language = segments[len(segments)-1]
+		segments = segments[:len(segments)-1]
+	}
+
+	if len(segments) > 1 {
+		product := strings.Join(segments[1:], "//")
+
+		if len(product) > 8 && strings.EqualFold(product[:8], "NONSGML ") {
+			product = product[8:]
+		}
+
+		info.Product = product
+	}
+
+	return info
+}
+
+// Dedup removes the events sharing the same UID and RECURRENCE-ID, keeping
+// the one with the highest SEQUENCE, or the latest LAST-MODIFIED when the
+// sequences are equal. The kept event takes the place of the first
+// occurrence.
+func (c *Calendar) Dedup() {
+	index := make(map[string]int)
+	events := make([]*Event, 0, len(c.Events))
+
+	for _, v := range c.Events {
+		key := instanceKey(v)
+
+		i, ok := index[key]
+
+		if !ok {
+			index[key] = len(events)
+			events = append(events, v)
+			continue
+		}
+
+		if isNewerRevision(v, events[i]) {
+			events[i] = v
+		}
+	}
+
+	c.Events = events
+}
+
+// Diff compares the events of the calendar with the ones of other, a newer
+// version of it, matching them by UID and RECURRENCE-ID. It returns the
+// events of other which are missing from the calendar, the events of the
+// calendar which are missing from other, and the events of other whose
+// SEQUENCE, LAST-MODIFIED or content differ, see Event.ContentHash.
+func (c *Calendar) Diff(other *Calendar) (added, removed, changed []*Event) {
+	added = make([]*Event, 0)
+	removed = make([]*Event, 0)
+	changed = make([]*Event, 0)
+
+	index := make(map[string]*Event, len(c.Events))
+
+	for _, v := range c.Events {
+		if key := instanceKey(v); index[key] == nil {
+			index[key] = v
+		}
+	}
+
+	seen := make(map[string]bool, len(other.Events))
+
+	for _, w := range other.Events {
+		key := instanceKey(w)
+
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		v, ok := index[key]
+
+		switch {
+		case !ok:
+			added = append(added, w)
+		case v.Sequence != w.Sequence || !v.Modified.Equal(w.Modified) || v.ContentHash() != w.ContentHash():
+			changed = append(changed, w)
+		}
+	}
+
+	for _, v := range c.Events {
+		if key := instanceKey(v); !seen[key] && index[key] == v {
+			removed = append(removed, v)
+		}
+	}
+
+	return added, removed, changed
+}
+
+// isNewerRevision checks if the event v supersedes the event w
+func isNewerRevision(v, w *Event) bool {
+	if v.Sequence != w.Sequence {
+		return v.Sequence > w.Sequence
+	}
+	return v.Modified.After(w.Modified)
+}
+
+// EventsOn returns the events occurring on the given day in the location
+// loc, which defaults to the system location. Timed events are included
+// when they overlap the day, even partially, and all-day events when the
+// day is within their date range. Recurring events give the instances
+// occurring on the day, as copies of the event moved to the start of each
+// instance, like Instances.
+func (c *Calendar) EventsOn(day time.Time, loc *time.Location) []*Event {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	day = day.In(loc)
+	dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	events := make([]*Event, 0)
+
+	for _, v := range c.Events {
+		if !hasProperty("RRULE", v.Properties) && !hasProperty("RDATE", v.Properties) {
+			if occursOn(v, day, dayStart, dayEnd) {
+				events = append(events, v)
+			}
+			continue
+		}
+
+		// the margin covers the all-day events, compared as floating dates
+		starts, err := v.Expand(dayStart.AddDate(0, 0, -1), dayEnd.AddDate(0, 0, 1), 0)
+
+		if err != nil {
+			if occursOn(v, day, dayStart, dayEnd) {
+				events = append(events, v)
+			}
+			continue
+		}
+
+		duration := v.EndDate.Sub(v.StartDate)
+
+		for _, start := range starts {
+			instance := v.Clone()
+			instance.StartDate = start
+			instance.EndDate = start.Add(duration)
+
+			if occursOn(instance, day, dayStart, dayEnd) {
+				events = append(events, instance)
+			}
+		}
+	}
+
+	return events
+}
+
+// occursOn checks if the event occurs on the day from dayStart to dayEnd,
+// see EventsOn
+func occursOn(v *Event, day, dayStart, dayEnd time.Time) bool {
+	if v.AllDay {
+		return occursOnDate(v, day)
+	}
+
+	end := v.EndDate
+
+	if end.Before(v.StartDate) {
+		end = v.StartDate
+	}
+
+	// instantaneous events belong to the day they start on
+	if end.Equal(v.StartDate) {
+		return !v.StartDate.Before(dayStart) && v.StartDate.Before(dayEnd)
+	}
+
+	return v.StartDate.Before(dayEnd) && end.After(dayStart)
+}
+
+// occursOnDate checks if the date of day is in the date range of an all-day
+// event. Dates are compared regardless of their location since all-day
+// events are floating.
+func occursOnDate(v *Event, day time.Time) bool {
+	date := floatingDate(day)
+	start := floatingDate(v.StartDate)
+	end := floatingDate(v.EndDate)
+
+	if !end.After(start) {
+		end = start.AddDate(0, 0, 1)
+	}
+
+	return !date.Before(start) && date.Before(end)
+}
+
+// floatingDate truncates t to its date, in UTC
+func floatingDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// InLocation returns a copy of the calendar whose events, todos and
+// journals times are converted to the location loc, the original calendar
+// is left untouched. Floating times, which don't belong to any location,
+// keep their wall clock and are interpreted in loc.
+func (c *Calendar) InLocation(loc *time.Location) *Calendar {
+	cc := c.Clone()
+
+	for _, v := range cc.Events {
+		v.StartDate = timeInLocation(v.StartDate, findProperty("DTSTART", v.Properties), v.AllDay, loc)
+		v.EndDate = timeInLocation(v.EndDate, findProperty("DTEND", v.Properties), v.AllDay, loc)
+	}
+
+	for _, t := range cc.Todos {
+		t.StartDate = timeInLocation(t.StartDate, findProperty("DTSTART", t.Properties), false, loc)
+		t.Due = timeInLocation(t.Due, findProperty("DUE", t.Properties), false, loc)
+	}
+
+	for _, j := range cc.Journals {
+		j.StartDate = timeInLocation(j.StartDate, findProperty("DTSTART", j.Properties), false, loc)
+	}
+
+	return cc
+}
+
+// timeInLocation converts t to loc, or moves its wall clock to loc when t
+// is floating. A time is floating when it comes from a property without
+// UTC designator nor TZID, or when it is in the local location for times
+// which were not parsed.
+func timeInLocation(t time.Time, prop *Property, allDay bool, loc *time.Location) time.Time {
+	if t.IsZero() {
+		return t
+	}
+
+	floating := t.Location() == time.Local
+
+	if prop != nil {
+		_, zoned := prop.ParamValue("TZID")
+		floating = !zoned && !strings.HasSuffix(prop.Value, "Z")
+	}
+
+	if floating || allDay || isDateProperty(prop) {
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+	}
+
+	return t.In(loc)
+}
+
+// PropertyMap indexes the calendar properties by name. The map is built on
+// each call, callers doing many lookups should keep it around.
+func (c *Calendar) PropertyMap() map[string][]*Property {
+	return propertyMap(c.Properties)
+}
+
+// Extensions returns the calendar properties which have no field of their
+// own, such as X-WR-RELCALID or X-PUBLISHED-TTL, in document order. Format
+// writes them back in the same order, after the known properties.
+func (c *Calendar) Extensions() []*Property {
+	properties := make([]*Property, 0)
+
+	for _, prop := range c.Properties {
+		if !calendarFields[prop.Name] {
+			properties = append(properties, prop)
+		}
+	}
+
+	return properties
+}
+
+// EventsByCategory returns the events having the category cat, compared
+// case-insensitively
+func (c *Calendar) EventsByCategory(cat string) []*Event {
+	events := make([]*Event, 0)
+
+	for _, v := range c.Events {
+		for _, category := range v.Categories {
+			if strings.EqualFold(category, cat) {
+				events = append(events, v)
+				break
+			}
+		}
+	}
+
+	return events
+}
+
+// Search returns the events matching query, see Event.Matches
+func (c *Calendar) Search(query string) []*Event {
+	events := make([]*Event, 0)
+
+	for _, v := range c.Events {
+		if v.Matches(query) {
+			events = append(events, v)
+		}
+	}
+
+	return events
+}
+
+// EventsByStatus returns the events having the status status, compared
+// case-insensitively
+func (c *Calendar) EventsByStatus(status string) []*Event {
+	events := make([]*Event, 0)
+
+	for _, v := range c.Events {
+		if strings.EqualFold(string(v.Status), status) {
+			events = append(events, v)
+		}
+	}
+
+	return events
+}
+
+// ReadFrom implements io.ReaderFrom, it parses the iCalendar read from r
+// into the calendar, replacing its content. Times are parsed in the system
+// location, use Parse to select another one.
+func (c *Calendar) ReadFrom(r io.Reader) (int64, error) {
+	cr := &countingReader{r: r}
+	parsed, err := Parse(cr, nil)
+
+	if err != nil {
+		return cr.n, err
+	}
+
+	*c = *parsed
+	return cr.n, nil
+}
+
+// Bytes formats the calendar with the default options, CRLF line endings
+// and folded lines as RFC 5545 requires, ready to be served as text/calendar
+func (c *Calendar) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := Format(&buf, c); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// countingReader counts the bytes read from r
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(b []byte) (int, error) {
+	n, err := cr.r.Read(b)
+	cr.n += int64(n)
+	return n, err
+}
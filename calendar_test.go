@@ -0,0 +1,473 @@
+package ical
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalendar_Walk(t *testing.T) {
+	file, _ := os.Open("fixtures/with-alarm.ics")
+	c, err := Parse(file, nil)
+	file.Close()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make([]string, 0)
+	c.Walk(func(component string, prop *Property) {
+		if prop.Name == "UID" || prop.Name == "VERSION" {
+			got = append(got, component+":"+prop.Name)
+		}
+	})
+
+	want := []string{"VCALENDAR:VERSION", "VEVENT:UID", "VALARM:UID"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk() visited %v, want %v", got, want)
+	}
+}
+
+func TestCalendar_Walk_documentOrder(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VTODO\r\nDTSTAMP:20200211T090000Z\r\nUID:todo\r\nEND:VTODO\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:event1\r\nDTSTART:20200211T100000Z\r\nEND:VEVENT\r\n" +
+		"BEGIN:VJOURNAL\r\nDTSTAMP:20200211T090000Z\r\nUID:journal\r\nEND:VJOURNAL\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:event2\r\nDTSTART:20200211T100000Z\r\nEND:VEVENT\r\n" +
+		"X-CALENDAR-LAST:value\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	walk := func(c *Calendar) []string {
+		got := make([]string, 0)
+		c.Walk(func(component string, prop *Property) {
+			if prop.Name == "UID" || prop.Name == "X-CALENDAR-LAST" {
+				got = append(got, component+":"+prop.Value)
+			}
+		})
+		return got
+	}
+
+	want := []string{"VCALENDAR:value", "VTODO:todo", "VEVENT:event1", "VJOURNAL:journal", "VEVENT:event2"}
+
+	if got := walk(c); !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk() visited %v, want %v", got, want)
+	}
+
+	if got := walk(c.Clone()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk() visited %v on a clone, want %v", got, want)
+	}
+
+	// added components come last, removed ones are skipped
+	c.Events = c.Events[1:]
+	c.AddEvent(&Event{UID: "added", Properties: []*Property{{Name: "UID", Value: "added"}}})
+	want = []string{"VCALENDAR:value", "VTODO:todo", "VJOURNAL:journal", "VEVENT:event2", "VEVENT:added"}
+
+	if got := walk(c); !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk() visited %v after changes, want %v", got, want)
+	}
+}
+
+func TestCalendar_Dedup(t *testing.T) {
+	newEvent := func(uid string, sequence int, modified int, recurrenceID string) *Event {
+		v := NewEvent()
+		v.UID = uid
+		v.Sequence = sequence
+		v.Modified = time.Date(2020, time.February, modified, 0, 0, 0, 0, time.UTC)
+		if recurrenceID != "" {
+			v.Properties = append(v.Properties, &Property{Name: "RECURRENCE-ID", Value: recurrenceID})
+		}
+		return v
+	}
+
+	a1 := newEvent("a", 0, 1, "")
+	a2 := newEvent("a", 1, 1, "")
+	b1 := newEvent("b", 0, 2, "")
+	b2 := newEvent("b", 0, 3, "")
+	a3 := newEvent("a", 0, 1, "20200211T090000Z")
+
+	c := NewCalendar()
+	c.Events = []*Event{a1, b1, a2, a3, b2}
+	c.Dedup()
+
+	want := []*Event{a2, b2, a3}
+
+	if !reflect.DeepEqual(c.Events, want) {
+		t.Errorf("Dedup() kept %v, want %v", c.Events, want)
+	}
+}
+
+func TestCalendar_Diff(t *testing.T) {
+	calendar := func(events string) *Calendar {
+		c, err := Parse(strings.NewReader("BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n"+events+"END:VCALENDAR\r\n"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	event := func(uid, dtstamp, props string) string {
+		return "BEGIN:VEVENT\r\nUID:" + uid + "\r\nDTSTAMP:" + dtstamp + "\r\nDTSTART:20200211T100000Z\r\n" + props + "END:VEVENT\r\n"
+	}
+
+	old := calendar(event("same", "20200211T090000Z", "SUMMARY:Same\r\n") +
+		event("sequence", "20200211T090000Z", "") +
+		event("content", "20200211T090000Z", "DESCRIPTION:Before\r\n") +
+		event("removed", "20200211T090000Z", ""))
+
+	updated := calendar(event("same", "20200301T090000Z", "SUMMARY:Same\r\n") +
+		event("added", "20200301T090000Z", "") +
+		event("sequence", "20200301T090000Z", "SEQUENCE:1\r\n") +
+		event("content", "20200301T090000Z", "DESCRIPTION:After\r\n") +
+		event("same", "20200301T090000Z", "RECURRENCE-ID:20200218T100000Z\r\n"))
+
+	keys := func(events []*Event) []string {
+		got := make([]string, 0)
+		for _, v := range events {
+			got = append(got, instanceKey(v))
+		}
+		return got
+	}
+
+	added, removed, changed := old.Diff(updated)
+
+	if want := []string{"added", "same/20200218T100000Z"}; !reflect.DeepEqual(keys(added), want) {
+		t.Errorf("Diff() added = %v, want %v", keys(added), want)
+	}
+
+	if want := []string{"removed"}; !reflect.DeepEqual(keys(removed), want) {
+		t.Errorf("Diff() removed = %v, want %v", keys(removed), want)
+	}
+
+	if want := []string{"sequence", "content"}; !reflect.DeepEqual(keys(changed), want) {
+		t.Errorf("Diff() changed = %v, want %v", keys(changed), want)
+	}
+
+	if changed[1] != updated.Events[3] {
+		t.Error("Diff() changed should hold the events of the other calendar")
+	}
+
+	added, removed, changed = old.Diff(old.Clone())
+
+	if len(added)+len(removed)+len(changed) != 0 {
+		t.Errorf("Diff() = %v, %v, %v with a clone, want no difference", added, removed, changed)
+	}
+}
+
+func TestCalendar_EventsOn(t *testing.T) {
+	allDay := NewEvent()
+	allDay.UID = "all-day"
+	allDay.AllDay = true
+	allDay.StartDate = time.Date(2020, time.February, 11, 0, 0, 0, 0, time.Local)
+	allDay.EndDate = time.Date(2020, time.February, 14, 0, 0, 0, 0, time.Local)
+
+	overnight := NewEvent()
+	overnight.UID = "overnight"
+	overnight.StartDate = time.Date(2020, time.February, 11, 22, 0, 0, 0, time.UTC)
+	overnight.EndDate = time.Date(2020, time.February, 12, 2, 0, 0, 0, time.UTC)
+
+	instant := NewEvent()
+	instant.UID = "instant"
+	instant.StartDate = time.Date(2020, time.February, 13, 0, 0, 0, 0, time.UTC)
+	instant.EndDate = instant.StartDate
+
+	daily := NewEvent()
+	daily.UID = "daily"
+	daily.StartDate = time.Date(2020, time.February, 8, 9, 0, 0, 0, time.UTC)
+	daily.EndDate = daily.StartDate.Add(time.Hour)
+	daily.Properties = []*Property{{Name: "RRULE", Value: "FREQ=DAILY;COUNT=5"}}
+
+	c := NewCalendar()
+	c.Events = []*Event{allDay, overnight, instant, daily}
+
+	tests := []struct {
+		day  time.Time
+		want []string
+	}{
+		{day: time.Date(2020, time.February, 10, 12, 0, 0, 0, time.UTC), want: []string{"daily"}},
+		{day: time.Date(2020, time.February, 11, 12, 0, 0, 0, time.UTC), want: []string{"all-day", "overnight", "daily"}},
+		{day: time.Date(2020, time.February, 12, 0, 0, 0, 0, time.UTC), want: []string{"all-day", "overnight", "daily"}},
+		{day: time.Date(2020, time.February, 13, 12, 0, 0, 0, time.UTC), want: []string{"all-day", "instant"}},
+		{day: time.Date(2020, time.February, 14, 12, 0, 0, 0, time.UTC), want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.day.Format(dateLayout), func(t *testing.T) {
+			got := make([]string, 0)
+			for _, v := range c.EventsOn(tt.day, time.UTC) {
+				got = append(got, v.UID)
+
+				if v.UID == "daily" && v.StartDate.Day() != tt.day.Day() {
+					t.Errorf("StartDate = %v, want the instance of the day", v.StartDate)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EventsOn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_method(t *testing.T) {
+	tests := []struct {
+		filename string
+		method   string
+		status   Status
+	}{
+		{filename: "fixtures/itip-request.ics", method: MethodRequest, status: StatusConfirmed},
+		{filename: "fixtures/itip-cancel.ics", method: MethodCancel, status: StatusCancelled},
+		{filename: "fixtures/itip-reply.ics", method: MethodReply},
+		{filename: "fixtures/itip-method-last.ics", method: MethodCancel, status: StatusCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			file, _ := os.Open(tt.filename)
+			c, err := Parse(file, nil, WithStrict(true))
+			file.Close()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if c.Method != tt.method {
+				t.Errorf("Method = %s, want %s", c.Method, tt.method)
+			}
+
+			if got := c.Events[0].Status; got != tt.status {
+				t.Errorf("Status = %s, want %s", got, tt.status)
+			}
+		})
+	}
+}
+
+func TestCalendar_InLocation(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:utc@example.com\r\n" +
+		"DTSTART:20200211T100000Z\r\nDTEND:20200211T110000Z\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:floating@example.com\r\n" +
+		"DTSTART:20200211T100000\r\nDTEND:20200211T110000\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:all-day@example.com\r\n" +
+		"DTSTART;VALUE=DATE:20200211\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paris, _ := time.LoadLocation("Europe/Paris")
+	converted := c.InLocation(paris)
+
+	tests := []struct {
+		uid  string
+		want time.Time
+	}{
+		{uid: "utc@example.com", want: time.Date(2020, time.February, 11, 11, 0, 0, 0, paris)},
+		{uid: "floating@example.com", want: time.Date(2020, time.February, 11, 10, 0, 0, 0, paris)},
+		{uid: "all-day@example.com", want: time.Date(2020, time.February, 11, 0, 0, 0, 0, paris)},
+	}
+	for i, tt := range tests {
+		t.Run(tt.uid, func(t *testing.T) {
+			got := converted.Events[i].StartDate
+			if !got.Equal(tt.want) || got.Location() != paris {
+				t.Errorf("StartDate = %v, want %v", got, tt.want)
+			}
+			if c.Events[i].StartDate.Location() != time.UTC {
+				t.Errorf("original StartDate was modified to %v", c.Events[i].StartDate)
+			}
+		})
+	}
+}
+
+var _ io.ReaderFrom = (*Calendar)(nil)
+
+func TestCalendar_ReadFrom(t *testing.T) {
+	ics, err := os.ReadFile("fixtures/example.ics")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var c Calendar
+	n, err := c.ReadFrom(bytes.NewReader(ics))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != int64(len(ics)) {
+		t.Errorf("ReadFrom() = %d, want %d bytes", n, len(ics))
+	}
+
+	if len(c.Events) == 0 {
+		t.Error("ReadFrom() should parse the events")
+	}
+
+	if _, err := c.ReadFrom(strings.NewReader("BEGIN:VEVENT\r\n")); err == nil {
+		t.Error("expected an error on invalid input")
+	}
+}
+
+func TestCalendar_Bytes(t *testing.T) {
+	start := time.Date(2020, time.February, 11, 10, 0, 0, 0, time.UTC)
+
+	c := NewCalendar()
+	c.SetProduct("-//xyz Corp//NONSGML PDA Calendar Version 1.0//EN", "2.0")
+	c.AddEvent(NewTimedEvent("uid@example.com", strings.Repeat("Meeting ", 20), start, start.Add(time.Hour)))
+
+	got, err := c.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Contains(got, []byte("\r\n ")) {
+		t.Errorf("Bytes() = %q, want folded lines", got)
+	}
+
+	if n := bytes.Count(got, []byte("\n")); n != bytes.Count(got, []byte("\r\n")) {
+		t.Errorf("Bytes() = %q, want CRLF line endings only", got)
+	}
+
+	if !bytes.HasSuffix(got, []byte("END:VCALENDAR\r\n")) {
+		t.Errorf("Bytes() = %q, want it to end with END:VCALENDAR and CRLF", got)
+	}
+}
+
+func TestCalendar_SetRefreshInterval(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\nX-PUBLISHED-TTL:PT1H\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.SetRefreshInterval(12 * time.Hour)
+
+	got, err := c.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "REFRESH-INTERVAL;VALUE=DURATION:PT12H\r\nX-PUBLISHED-TTL:PT12H\r\nEND:VCALENDAR\r\n"
+
+	if !bytes.HasSuffix(got, []byte(want)) || bytes.Contains(got, []byte("PT1H")) {
+		t.Errorf("Bytes() = %q, want it to end with %q", got, want)
+	}
+
+	c.SetRefreshInterval(0)
+
+	if len(c.Properties) != 2 {
+		t.Errorf("Properties = %v, want the refresh interval removed", c.Properties)
+	}
+}
+
+func TestCalendar_ProductInfo(t *testing.T) {
+	tests := []struct {
+		prodID string
+		want   ProductInfo
+	}{
+		{prodID: "-//Google Inc//Google Calendar 70.9054//EN", want: ProductInfo{Vendor: "Google Inc", Product: "Google Calendar 70.9054", Language: "EN"}},
+		{prodID: "-//xyz Corp//NONSGML PDA Calendar Version 1.0//EN", want: ProductInfo{Vendor: "xyz Corp", Product: "PDA Calendar Version 1.0", Language: "EN"}},
+		{prodID: "+//IDN example.com//Calendar//DE", want: ProductInfo{Vendor: "IDN example.com", Product: "Calendar", Language: "DE"}},
+		{prodID: "-//Apple Inc.//macOS 11.2//Calendar//EN", want: ProductInfo{Vendor: "Apple Inc.", Product: "macOS 11.2//Calendar", Language: "EN"}},
+		{prodID: "-//Vendor//Product", want: ProductInfo{Vendor: "Vendor", Product: "Product"}},
+		{prodID: "-//Vendor", want: ProductInfo{Vendor: "Vendor"}},
+		{prodID: "Microsoft Exchange Server 2010", want: ProductInfo{Product: "Microsoft Exchange Server 2010"}},
+		{prodID: "", want: ProductInfo{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prodID, func(t *testing.T) {
+			c := NewCalendar()
+			c.Prodid = tt.prodID
+
+			if got := c.ProductInfo(); got != tt.want {
+				t.Errorf("ProductInfo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalendar_EventsByCategory(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:work@example.com\r\nDTSTART:20200211T100000Z\r\n" +
+		"CATEGORIES:Work,Meeting\r\nSTATUS:CONFIRMED\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:home@example.com\r\nDTSTART:20200211T100000Z\r\n" +
+		"CATEGORIES:Home\r\nCATEGORIES:Meeting\r\nSTATUS:TENTATIVE\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uids := func(events []*Event) []string {
+		got := make([]string, 0)
+		for _, v := range events {
+			got = append(got, v.UID)
+		}
+		return got
+	}
+
+	tests := []struct {
+		name   string
+		events []*Event
+		want   []string
+	}{
+		{name: "Category", events: c.EventsByCategory("work"), want: []string{"work@example.com"}},
+		{name: "Repeated category", events: c.EventsByCategory("MEETING"), want: []string{"work@example.com", "home@example.com"}},
+		{name: "Unknown category", events: c.EventsByCategory("Misc"), want: []string{}},
+		{name: "Status", events: c.EventsByStatus("confirmed"), want: []string{"work@example.com"}},
+		{name: "Search", events: c.Search("hom"), want: []string{"home@example.com"}},
+		{name: "Search nothing", events: c.Search("lunch"), want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := uids(tt.events); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalendar_Extensions(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nX-WR-RELCALID:abc\r\nPRODID:test\r\nX-PUBLISHED-TTL:PT1H\r\nVERSION:2.0\r\n" +
+		"X-WR-CALNAME:Work\r\nX-CUSTOM;X-PARAM=1:value\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:uid@example.com\r\nDTSTART:20200211T100000Z\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"X-WR-RELCALID:abc", "X-PUBLISHED-TTL:PT1H", "X-CUSTOM;X-PARAM=1:value"}
+
+	contentLines := func(properties []*Property) []string {
+		lines := make([]string, 0, len(properties))
+		for _, prop := range properties {
+			lines = append(lines, formatProperty(prop))
+		}
+		return lines
+	}
+
+	if got := contentLines(c.Extensions()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Extensions() = %q, want %q", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	formatted, err := Parse(&buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := contentLines(formatted.Extensions()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Extensions() = %q after a round trip, want %q", got, want)
+	}
+}
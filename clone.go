@@ -0,0 +1,187 @@
+package ical
+
+// Clone returns a deep copy of the calendar, sharing nothing with the
+// original
+func (c *Calendar) Clone() *Calendar {
+	cc := *c
+	cc.Properties = cloneProperties(c.Properties)
+	cc.Events = cloneSlice(c.Events, (*Event).Clone)
+	cc.Todos = cloneSlice(c.Todos, cloneTodo)
+	cc.Journals = cloneSlice(c.Journals, cloneJournal)
+	cc.FreeBusys = cloneSlice(c.FreeBusys, cloneFreeBusy)
+	cc.Timezones = cloneSlice(c.Timezones, cloneTimezone)
+	cc.Categories = cloneSlice(c.Categories, nil)
+	cc.Images = cloneSlice(c.Images, cloneImage)
+	cc.Components = cloneSlice(c.Components, cloneComponent)
+	cc.Warnings = cloneSlice(c.Warnings, nil)
+
+	// the clones keep the document order of the components
+	clones := make(map[interface{}]interface{}, len(c.order))
+	components := cc.components()
+
+	for i, comp := range c.components() {
+		clones[comp] = components[i]
+	}
+
+	cc.order = make([]interface{}, 0, len(c.order))
+
+	for _, comp := range c.order {
+		if clone, ok := clones[comp]; ok {
+			cc.order = append(cc.order, clone)
+		}
+	}
+
+	return &cc
+}
+
+// Clone returns a deep copy of the event, sharing nothing with the original
+func (v *Event) Clone() *Event {
+	vv := *v
+	vv.Properties = cloneProperties(v.Properties)
+	vv.Alarms = cloneSlice(v.Alarms, cloneAlarm)
+	vv.Contacts = cloneSlice(v.Contacts, nil)
+	vv.Categories = cloneSlice(v.Categories, nil)
+	vv.Attachments = cloneSlice(v.Attachments, cloneAttachment)
+	vv.Conferences = cloneSlice(v.Conferences, func(conference Conference) Conference {
+		conference.Features = cloneSlice(conference.Features, nil)
+		return conference
+	})
+	vv.Attendees = cloneSlice(v.Attendees, cloneAttendee)
+	vv.Images = cloneSlice(v.Images, cloneImage)
+	vv.ExRules = cloneSlice(v.ExRules, cloneRecurrence)
+
+	if v.Geo != nil {
+		geo := *v.Geo
+		vv.Geo = &geo
+	}
+
+	if v.Organizer != nil {
+		organizer := *v.Organizer
+		vv.Organizer = &organizer
+	}
+
+	return &vv
+}
+
+// cloneComponent copies the component, its Value is shared
+func cloneComponent(comp *Component) *Component {
+	cc := *comp
+	cc.Properties = cloneProperties(comp.Properties)
+	cc.Components = cloneSlice(comp.Components, cloneComponent)
+	return &cc
+}
+
+func cloneRecurrence(r *Recurrence) *Recurrence {
+	rr := *r
+	rr.BySecond = cloneSlice(r.BySecond, nil)
+	rr.ByMinute = cloneSlice(r.ByMinute, nil)
+	rr.ByHour = cloneSlice(r.ByHour, nil)
+	rr.ByDay = cloneSlice(r.ByDay, nil)
+	rr.ByMonthDay = cloneSlice(r.ByMonthDay, nil)
+	rr.ByMonth = cloneSlice(r.ByMonth, nil)
+	rr.BySetPos = cloneSlice(r.BySetPos, nil)
+	return &rr
+}
+
+func cloneTodo(t *Todo) *Todo {
+	tt := *t
+	tt.Properties = cloneProperties(t.Properties)
+	tt.Alarms = cloneSlice(t.Alarms, cloneAlarm)
+	return &tt
+}
+
+func cloneJournal(j *Journal) *Journal {
+	jj := *j
+	jj.Properties = cloneProperties(j.Properties)
+	return &jj
+}
+
+func cloneFreeBusy(fb *FreeBusy) *FreeBusy {
+	fbb := *fb
+	fbb.Properties = cloneProperties(fb.Properties)
+	fbb.Periods = cloneSlice(fb.Periods, func(period *Period) *Period {
+		p := *period
+		return &p
+	})
+	return &fbb
+}
+
+func cloneTimezone(z *Timezone) *Timezone {
+	zz := *z
+	zz.Properties = cloneProperties(z.Properties)
+	zz.Standard = cloneSlice(z.Standard, cloneTimezoneRule)
+	zz.Daylight = cloneSlice(z.Daylight, cloneTimezoneRule)
+	return &zz
+}
+
+func cloneTimezoneRule(r *TimezoneRule) *TimezoneRule {
+	rr := *r
+	rr.Properties = cloneProperties(r.Properties)
+	return &rr
+}
+
+func cloneAlarm(a *Alarm) *Alarm {
+	aa := *a
+	aa.Properties = cloneProperties(a.Properties)
+	aa.Attachments = cloneSlice(a.Attachments, cloneAttachment)
+	return &aa
+}
+
+func cloneAttachment(attachment *Attachment) *Attachment {
+	a := *attachment
+	a.Data = cloneSlice(attachment.Data, nil)
+	return &a
+}
+
+func cloneImage(image *Image) *Image {
+	i := *image
+	i.Data = cloneSlice(image.Data, nil)
+	i.Display = cloneSlice(image.Display, nil)
+	return &i
+}
+
+func cloneAttendee(attendee *Attendee) *Attendee {
+	a := *attendee
+	a.DelegatedFrom = cloneSlice(attendee.DelegatedFrom, nil)
+	a.DelegatedTo = cloneSlice(attendee.DelegatedTo, nil)
+	a.Member = cloneSlice(attendee.Member, nil)
+	return &a
+}
+
+func cloneProperties(properties []*Property) []*Property {
+	return cloneSlice(properties, func(prop *Property) *Property {
+		p := *prop
+
+		if prop.Params != nil {
+			p.Params = make(map[string]*Param, len(prop.Params))
+
+			for name, param := range prop.Params {
+				if param != nil {
+					param = &Param{Values: cloneSlice(param.Values, nil), lengths: cloneSlice(param.lengths, nil)}
+				}
+				p.Params[name] = param
+			}
+		}
+
+		return &p
+	})
+}
+
+// cloneSlice copies s, cloning each element with clone when it is not nil.
+// A nil slice stays nil, an empty one stays empty.
+func cloneSlice[T any](s []T, clone func(T) T) []T {
+	if s == nil {
+		return nil
+	}
+
+	out := make([]T, len(s))
+
+	for i, e := range s {
+		if clone != nil {
+			e = clone(e)
+		}
+		out[i] = e
+	}
+
+	return out
+}
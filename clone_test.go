@@ -0,0 +1,45 @@
+package ical
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestCalendar_Clone(t *testing.T) {
+	file, _ := os.Open("fixtures/with-alarm.ics")
+	c, err := Parse(file, nil)
+	file.Close()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clone := c.Clone()
+
+	if !reflect.DeepEqual(clone, c) {
+		t.Fatal("Clone() should be equal to the original")
+	}
+
+	v := clone.Events[0]
+	v.Summary = "changed"
+	v.Properties[0].Value = "changed"
+	v.Properties[0].Params["X-CHANGED"] = &Param{Values: []string{"1"}}
+	v.Alarms[0].Properties[0].Value = "changed"
+	clone.Properties[0].Value = "changed"
+	clone.Events = append(clone.Events, NewEvent())
+
+	orig := c.Events[0]
+
+	if orig.Summary == "changed" || orig.Properties[0].Value == "changed" || orig.Alarms[0].Properties[0].Value == "changed" {
+		t.Error("modifying the clone changed the original event")
+	}
+
+	if _, ok := orig.Properties[0].Params["X-CHANGED"]; ok {
+		t.Error("the clone shares the params of the original")
+	}
+
+	if c.Properties[0].Value == "changed" || len(c.Events) != len(clone.Events)-1 {
+		t.Error("modifying the clone changed the original calendar")
+	}
+}
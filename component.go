@@ -0,0 +1,53 @@
+package ical
+
+import (
+	"strings"
+	"sync"
+)
+
+// A Component is a component the package doesn't model, VAVAILABILITY or
+// VPOLL for instance, as read from a calendar. It holds the raw properties
+// and nested components, the known ones like VEVENT included.
+type Component struct {
+	Name       string
+	Properties []*Property
+	Components []*Component
+	Value      interface{} // set by the ComponentHandler, nil otherwise
+}
+
+// A ComponentHandler teaches the parser and the formatter about a
+// component the package doesn't model, see RegisterComponent
+type ComponentHandler interface {
+	// ParseComponent is called once the END delimiter of the component is
+	// read, it may validate it and set its Value. An error stops the
+	// parsing.
+	ParseComponent(comp *Component) error
+
+	// FormatComponent is called before the component is written, it may
+	// rebuild its properties and nested components from its Value. An error
+	// stops the formatting.
+	FormatComponent(comp *Component) error
+}
+
+// componentHandlers maps a component name to its handler
+var componentHandlers = struct {
+	sync.RWMutex
+	m map[string]ComponentHandler
+}{m: map[string]ComponentHandler{}}
+
+// RegisterComponent sets the handler of the components named name found in
+// VCALENDAR, they are then kept in Calendar.Components instead of being
+// read as BEGIN and END properties. It is safe for concurrent use.
+func RegisterComponent(name string, handler ComponentHandler) {
+	componentHandlers.Lock()
+	defer componentHandlers.Unlock()
+	componentHandlers.m[strings.ToUpper(name)] = handler
+}
+
+// componentHandler returns the registered handler of a component, nil when
+// there is none
+func componentHandler(name string) ComponentHandler {
+	componentHandlers.RLock()
+	defer componentHandlers.RUnlock()
+	return componentHandlers.m[strings.ToUpper(name)]
+}
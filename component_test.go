@@ -0,0 +1,124 @@
+package ical
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// availability is a minimal VAVAILABILITY handler, its Value is the number
+// of AVAILABLE components
+type availability struct{}
+
+func (availability) ParseComponent(comp *Component) error {
+	if !hasProperty("UID", comp.Properties) {
+		return errors.New("missing required property \"uid\"")
+	}
+
+	comp.Value = len(comp.Components)
+	return nil
+}
+
+func (availability) FormatComponent(comp *Component) error {
+	if comp.Value == nil {
+		return errors.New("not parsed")
+	}
+	return nil
+}
+
+func TestRegisterComponent(t *testing.T) {
+	RegisterComponent("vavailability", availability{})
+
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VAVAILABILITY\r\nUID:availability@example.com\r\nDTSTAMP:20200211T090000Z\r\n" +
+		"BEGIN:AVAILABLE\r\nUID:available@example.com\r\nDTSTART:20200211T090000Z\r\nEND:AVAILABLE\r\n" +
+		"BEGIN:VEVENT\r\nSUMMARY:nested\r\nEND:VEVENT\r\n" +
+		"END:VAVAILABILITY\r\n" +
+		"BEGIN:X-UNKNOWN\r\nX-PROP:value\r\nEND:X-UNKNOWN\r\n" +
+		"END:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.Components) != 1 {
+		t.Fatalf("Components = %d, want 1", len(c.Components))
+	}
+
+	comp := c.Components[0]
+
+	if comp.Name != "VAVAILABILITY" || len(comp.Properties) != 2 || comp.Value != 2 {
+		t.Errorf("Components[0] = %+v, want VAVAILABILITY with 2 properties and 2 components", comp)
+	}
+
+	if got := comp.Components[1]; got.Name != "VEVENT" || got.Properties[0].Value != "nested" {
+		t.Errorf("Components[0].Components[1] = %+v, want the raw VEVENT", got)
+	}
+
+	if len(c.Events) != 0 {
+		t.Errorf("Events = %d, want the nested VEVENT kept raw", len(c.Events))
+	}
+
+	// the components without handler are still read as properties
+	if !hasProperty("X-PROP", c.Properties) {
+		t.Errorf("Properties = %v, want X-PROP", c.Properties)
+	}
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "BEGIN:VAVAILABILITY\r\nUID:availability@example.com\r\nDTSTAMP:20200211T090000Z\r\n" +
+		"BEGIN:AVAILABLE\r\nUID:available@example.com\r\nDTSTART:20200211T090000Z\r\nEND:AVAILABLE\r\n" +
+		"BEGIN:VEVENT\r\nSUMMARY:nested\r\nEND:VEVENT\r\n" +
+		"END:VAVAILABILITY\r\n"
+
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Errorf("Format() = %q, want it to contain %q", got, want)
+	}
+
+	comp.Value = nil
+
+	if err := Format(&buf, c); err == nil || err.Error() != "error in VAVAILABILITY: not parsed" {
+		t.Errorf("Format() error = %v, want the handler error", err)
+	}
+}
+
+func TestRegisterComponent_errors(t *testing.T) {
+	RegisterComponent("VAVAILABILITY", availability{})
+
+	tests := []struct {
+		name string
+		ics  string
+		want string
+	}{
+		{
+			name: "Handler",
+			ics:  "BEGIN:VAVAILABILITY\r\nDTSTAMP:20200211T090000Z\r\nEND:VAVAILABILITY\r\n",
+			want: "error in VAVAILABILITY: missing required property \"uid\"",
+		},
+		{
+			name: "Mismatched END",
+			ics:  "BEGIN:VAVAILABILITY\r\nBEGIN:AVAILABLE\r\nEND:VAVAILABILITY\r\n",
+			want: "line 6: found END:VAVAILABILITY, expected END:AVAILABLE",
+		},
+		{
+			name: "Unterminated",
+			ics:  "BEGIN:VAVAILABILITY\r\nUID:availability@example.com\r\n",
+			want: "line 6: found END:VCALENDAR, expected END:VAVAILABILITY",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" + tt.ics + "END:VCALENDAR\r\n"
+
+			_, err := Parse(strings.NewReader(ics), nil)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Parse() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
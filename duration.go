@@ -0,0 +1,216 @@
+package ical
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseDuration transforms an iCalendar duration into a time.Duration
+//
+// dur-value  = (["+"] / "-") "P" (dur-date / dur-time / dur-week)
+// dur-date   = dur-day [dur-time]
+// dur-time   = "T" (dur-hour / dur-minute / dur-second)
+// dur-week   = 1*DIGIT "W"
+// dur-hour   = 1*DIGIT "H" [dur-minute]
+// dur-minute = 1*DIGIT "M" [dur-second]
+// dur-second = 1*DIGIT "S"
+// dur-day    = 1*DIGIT "D"
+func ParseDuration(s string) (time.Duration, error) {
+	days, clock, err := parseNominalDuration(s)
+
+	if err != nil {
+		return 0, err
+	}
+
+	d := time.Duration(days) * 24 * time.Hour
+
+	// both parts share the sign of the duration
+	if (d > 0 && clock > math.MaxInt64-d) || (d < 0 && clock < math.MinInt64-d) {
+		return 0, fmt.Errorf("invalid duration %q, out of range", s)
+	}
+
+	return d + clock, nil
+}
+
+// maxDurationDays is the number of days of the longest time.Duration
+const maxDurationDays = math.MaxInt64 / int64(24*time.Hour)
+
+// parseNominalDuration splits an iCalendar duration into its nominal days,
+// weeks included, and its exact time. Both share the sign of the duration.
+// Days follow the wall clock across daylight saving time transitions while
+// the time is elapsed as is.
+func parseNominalDuration(s string) (int, time.Duration, error) {
+	value := s
+	sign := 1
+
+	if strings.HasPrefix(value, "-") {
+		sign = -1
+		value = value[1:]
+	} else if strings.HasPrefix(value, "+") {
+		value = value[1:]
+	}
+
+	if !strings.HasPrefix(value, "P") {
+		return 0, 0, fmt.Errorf("invalid duration %q, expected \"P\"", s)
+	}
+
+	value = value[1:]
+
+	// the week form can't be combined with any other unit
+	if strings.Contains(value, "W") {
+		weeks := strings.TrimSuffix(value, "W")
+
+		if !isDigits(weeks) {
+			return 0, 0, fmt.Errorf("invalid duration %q, weeks can't be combined with other units", s)
+		}
+
+		n, err := strconv.Atoi(weeks)
+
+		if err != nil || int64(n) > maxDurationDays/7 {
+			return 0, 0, fmt.Errorf("invalid duration %q, out of range", s)
+		}
+
+		return sign * n * 7, 0, nil
+	}
+
+	date, clock, hasTime := strings.Cut(value, "T")
+
+	if date == "" && !hasTime {
+		return 0, 0, fmt.Errorf("invalid duration %q, missing a duration unit", s)
+	}
+
+	var days int
+	var d time.Duration
+
+	if date != "" {
+		n, rest, err := durationUnit(date, 'D')
+
+		if err != nil || rest != "" {
+			return 0, 0, fmt.Errorf("invalid duration %q, expected days", s)
+		}
+
+		if int64(n) > maxDurationDays {
+			return 0, 0, fmt.Errorf("invalid duration %q, out of range", s)
+		}
+
+		days = n
+	}
+
+	if hasTime {
+		if clock == "" {
+			return 0, 0, fmt.Errorf("invalid duration %q, missing a time unit after \"T\"", s)
+		}
+
+		for _, unit := range []struct {
+			designator byte
+			duration   time.Duration
+		}{
+			{'H', time.Hour},
+			{'M', time.Minute},
+			{'S', time.Second},
+		} {
+			n, rest, err := durationUnit(clock, unit.designator)
+
+			if err != nil {
+				return 0, 0, fmt.Errorf("invalid duration %q: %v", s, err)
+			}
+
+			if int64(n) > int64(math.MaxInt64-d)/int64(unit.duration) {
+				return 0, 0, fmt.Errorf("invalid duration %q, out of range", s)
+			}
+
+			d += time.Duration(n) * unit.duration
+			clock = rest
+		}
+
+		if clock != "" {
+			return 0, 0, fmt.Errorf("invalid duration %q, unexpected %q", s, clock)
+		}
+	}
+
+	return sign * days, time.Duration(sign) * d, nil
+}
+
+// formatDuration transforms a time.Duration into an iCalendar duration, the
+// inverse of ParseDuration. Sub-second precision is dropped.
+func formatDuration(d time.Duration) string {
+	var b strings.Builder
+
+	if d < 0 {
+		b.WriteByte('-')
+		d = -d
+	}
+
+	b.WriteByte('P')
+
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+
+	if days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+	}
+
+	if d < time.Second && days > 0 {
+		return b.String()
+	}
+
+	hours := d / time.Hour
+	minutes := (d - hours*time.Hour) / time.Minute
+	seconds := (d - hours*time.Hour - minutes*time.Minute) / time.Second
+
+	b.WriteByte('T')
+
+	if hours > 0 {
+		fmt.Fprintf(&b, "%dH", hours)
+	}
+
+	// the minutes can't be skipped between hours and seconds
+	if minutes > 0 || (hours > 0 && seconds > 0) {
+		fmt.Fprintf(&b, "%dM", minutes)
+	}
+
+	if seconds > 0 || (hours == 0 && minutes == 0) {
+		fmt.Fprintf(&b, "%dS", seconds)
+	}
+
+	return b.String()
+}
+
+// durationUnit reads the leading "1*DIGIT designator" of value, if present
+func durationUnit(value string, designator byte) (int, string, error) {
+	i := strings.IndexByte(value, designator)
+
+	if i < 0 {
+		return 0, value, nil
+	}
+
+	if !isDigits(value[:i]) {
+		return 0, value, fmt.Errorf("expected digits before %q", designator)
+	}
+
+	n, err := strconv.Atoi(value[:i])
+
+	if err != nil {
+		return 0, value, fmt.Errorf("%q is out of range", value[:i])
+	}
+
+	return n, value[i+1:], nil
+}
+
+// isDigits checks that s is made of at least one ASCII digit
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,86 @@
+package ical
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "P15DT5H0M20S", want: 15*24*time.Hour + 5*time.Hour + 20*time.Second},
+		{value: "P7W", want: 7 * 7 * 24 * time.Hour},
+		{value: "P2W", want: 2 * 7 * 24 * time.Hour},
+		{value: "PT1H0M0S", want: time.Hour},
+		{value: "PT15M", want: 15 * time.Minute},
+		{value: "-PT15M", want: -15 * time.Minute},
+		{value: "+PT15M", want: 15 * time.Minute},
+		{value: "-P1DT12H", want: -36 * time.Hour},
+		{value: "-PT10080M", want: -7 * 24 * time.Hour},
+		{value: "-P7D", want: -7 * 24 * time.Hour},
+		{value: "P1D", want: 24 * time.Hour},
+		{value: "P0D", want: 0},
+		{value: "PT0S", want: 0},
+		{value: "PT1H30M", want: 90 * time.Minute},
+		{value: "", wantErr: true},
+		{value: "P", wantErr: true},
+		{value: "PT", wantErr: true},
+		{value: "15M", wantErr: true},
+		{value: "P1W1D", wantErr: true},
+		{value: "P1DT1W", wantErr: true},
+		{value: "PW", wantErr: true},
+		{value: "P1H", wantErr: true},
+		{value: "PT1D", wantErr: true},
+		{value: "PT1M1H", wantErr: true},
+		{value: "PT-1H", wantErr: true},
+		{value: "P1DT", wantErr: true},
+		{value: "P99999999999999999999D", wantErr: true},
+		{value: "P106752D", wantErr: true},
+		{value: "P15251W", wantErr: true},
+		{value: "PT2562048H", wantErr: true},
+		{value: "PT2562047H47M16S", want: 2562047*time.Hour + 47*time.Minute + 16*time.Second},
+		{value: "PT2562047H48M", wantErr: true},
+		{value: "P106751DT24H", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseDuration(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseDuration() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_formatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: 0, want: "PT0S"},
+		{d: 15 * time.Minute, want: "PT15M"},
+		{d: -15 * time.Minute, want: "-PT15M"},
+		{d: 90 * time.Minute, want: "PT1H30M"},
+		{d: time.Hour + 20*time.Second, want: "PT1H0M20S"},
+		{d: 24 * time.Hour, want: "P1D"},
+		{d: 15*24*time.Hour + 5*time.Hour + 20*time.Second, want: "P15DT5H0M20S"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := formatDuration(tt.d)
+			if got != tt.want {
+				t.Errorf("formatDuration() = %q, want %q", got, tt.want)
+			}
+			if back, err := ParseDuration(got); err != nil || back != tt.d {
+				t.Errorf("ParseDuration() = %v, %v, want %v", back, err, tt.d)
+			}
+		})
+	}
+}
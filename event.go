@@ -0,0 +1,123 @@
+package ical
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DescriptionHTML returns the HTML alternate representation of the event
+// description, taken from the ALTREP param of the DESCRIPTION property.
+// A "data:" URI is decoded and its content returned, any other URI is
+// returned as is so the caller can fetch it.
+func (v *Event) DescriptionHTML() (string, bool) {
+	prop := findProperty("DESCRIPTION", v.Properties)
+
+	if prop == nil {
+		return "", false
+	}
+
+	altrep, ok := prop.Params["ALTREP"]
+
+	if !ok || altrep == nil || len(altrep.Values) == 0 {
+		return "", false
+	}
+
+	// An unquoted ALTREP is split on commas by the lexer, glue it back
+	uri := strings.Join(altrep.Values, ",")
+
+	if !strings.HasPrefix(uri, "data:") {
+		return uri, true
+	}
+
+	return decodeDataURI(uri)
+}
+
+// decodeDataURI extracts the content of a RFC 2397 "data:" URI
+//
+// dataurl   = "data:" [ mediatype ] [ ";base64" ] "," data
+func decodeDataURI(uri string) (string, bool) {
+	header, data, found := strings.Cut(strings.TrimPrefix(uri, "data:"), ",")
+
+	if !found {
+		return "", false
+	}
+
+	if strings.HasSuffix(header, ";base64") {
+		decoded, err := base64.StdEncoding.DecodeString(data)
+
+		if err != nil {
+			return "", false
+		}
+
+		return string(decoded), true
+	}
+
+	decoded, err := url.PathUnescape(data)
+
+	if err != nil {
+		return "", false
+	}
+
+	return decoded, true
+}
+
+// IsBusy checks if the event consumes time on the calendar, which is the
+// case unless it is TRANSPARENT or CANCELLED. Free/busy computations
+// must only account for busy events. With the WithOutlookBusyStatus option,
+// a FREE Outlook event without TRANSP is TRANSPARENT.
+func (v *Event) IsBusy() bool {
+	return v.Transparency != TransparencyTransparent && v.Status != StatusCancelled
+}
+
+// Raw returns the unfolded source text the event was parsed from, it is
+// only retained when parsing with the WithRawSource option.
+func (v *Event) Raw() string {
+	return v.raw
+}
+
+// PropertyMap indexes the event properties by name. The map is built on
+// each call, callers doing many lookups should keep it around.
+func (v *Event) PropertyMap() map[string][]*Property {
+	return propertyMap(v.Properties)
+}
+
+// Matches checks if query is found, case-insensitively, in the summary,
+// description, location or categories of the event. An empty query matches
+// every event.
+func (v *Event) Matches(query string) bool {
+	query = strings.ToLower(query)
+
+	fields := append([]string{v.Summary, v.Description}, v.Categories...)
+
+	if prop := findProperty("LOCATION", v.Properties); prop != nil {
+		fields = append(fields, prop.Text())
+	}
+
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ContentHash returns a hash of the iCalendar representation of the event,
+// its alarms included, which changes whenever the event content does. The
+// DTSTAMP is left out since producers usually set it to the export time.
+func (v *Event) ContentHash() string {
+	h := sha256.New()
+	f := &formatter{w: h, formatConfig: defaultFormatConfig()}
+	f.clock = func() time.Time { return time.Time{} }
+	f.uid = func() string { return "" }
+
+	vv := *v
+	vv.Timestamp = time.Time{}
+	f.formatEvent(&vv)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,245 @@
+package ical
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestEvent_DescriptionHTML(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]*Param
+		want   string
+		wantOk bool
+	}{
+		{
+			name:   "No ALTREP",
+			params: map[string]*Param{},
+			want:   "",
+			wantOk: false,
+		},
+		{
+			name: "URI ALTREP",
+			params: map[string]*Param{
+				"ALTREP": {Values: []string{"cid:part1.0001@example.org"}},
+			},
+			want:   "cid:part1.0001@example.org",
+			wantOk: true,
+		},
+		{
+			name: "Percent encoded data URI",
+			params: map[string]*Param{
+				"ALTREP": {Values: []string{"data:text/html,%3Cp%3EHello%2C world%3C%2Fp%3E"}},
+			},
+			want:   "<p>Hello, world</p>",
+			wantOk: true,
+		},
+		{
+			name: "Unquoted data URI split on comma",
+			params: map[string]*Param{
+				"ALTREP": {Values: []string{"data:text/html", "<b>bold</b>"}},
+			},
+			want:   "<b>bold</b>",
+			wantOk: true,
+		},
+		{
+			name: "Base64 data URI",
+			params: map[string]*Param{
+				"ALTREP": {Values: []string{"data:text/html;base64,PGk+aXRhbGljPC9pPg=="}},
+			},
+			want:   "<i>italic</i>",
+			wantOk: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewEvent()
+			v.Properties = append(v.Properties, &Property{
+				Name:   "DESCRIPTION",
+				Params: tt.params,
+				Value:  "plain text",
+			})
+			got, ok := v.DescriptionHTML()
+			if ok != tt.wantOk {
+				t.Errorf("DescriptionHTML() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if got != tt.want {
+				t.Errorf("DescriptionHTML() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvent_IsBusy(t *testing.T) {
+	file, _ := os.Open("fixtures/with-alarm.ics")
+	c, err := Parse(file, nil)
+	file.Close()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := c.Events[0]; v.Transparency != TransparencyTransparent || v.IsBusy() {
+		t.Errorf("transparent event should not be busy")
+	}
+
+	if v := NewEvent(); v.Transparency != TransparencyOpaque || !v.IsBusy() {
+		t.Errorf("event should be opaque and busy by default")
+	}
+}
+
+func TestEvent_Raw(t *testing.T) {
+	event := "BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:uid@example.com\r\n" +
+		"DTSTART:20200211T100000Z\r\nSUMMARY:Folded\r\n  summary\r\nEND:VEVENT\r\n"
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" + event + "END:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := c.Events[0].Raw(); got != "" {
+		t.Errorf("Raw() = %q, want empty without WithRawSource", got)
+	}
+
+	c, err = Parse(strings.NewReader(ics), nil, WithRawSource(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := c.Events[0].Raw(), strings.ReplaceAll(event, "\r\n ", ""); got != want {
+		t.Errorf("Raw() = %q, want %q", got, want)
+	}
+}
+
+func TestEvent_PropertyMap(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:uid@example.com\r\nDTSTART:20200211T100000Z\r\n" +
+		"ATTENDEE:mailto:a@example.com\r\nATTENDEE:mailto:b@example.com\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := c.Events[0].PropertyMap()
+
+	if got := m["UID"]; len(got) != 1 || got[0].Value != "uid@example.com" {
+		t.Errorf("UID = %v, want a single uid@example.com", got)
+	}
+
+	attendees := m["ATTENDEE"]
+
+	if len(attendees) != 2 || attendees[0].Value != "mailto:a@example.com" || attendees[1].Value != "mailto:b@example.com" {
+		t.Errorf("ATTENDEE = %v, want both attendees in document order", attendees)
+	}
+
+	if _, ok := m["LOCATION"]; ok {
+		t.Error("LOCATION should be missing")
+	}
+}
+
+func TestEvent_IsBusy_outlook(t *testing.T) {
+	tests := []struct {
+		name     string
+		props    string
+		opts     []Option
+		wantBusy bool
+	}{
+		{name: "Ignored by default", props: "X-MICROSOFT-CDO-BUSYSTATUS:FREE\r\n", wantBusy: true},
+		{name: "Free", props: "X-MICROSOFT-CDO-BUSYSTATUS:FREE\r\n", opts: []Option{WithOutlookBusyStatus(true)}, wantBusy: false},
+		{name: "Out of office", props: "X-MICROSOFT-CDO-BUSYSTATUS:OOF\r\n", opts: []Option{WithOutlookBusyStatus(true)}, wantBusy: true},
+		{name: "TRANSP wins", props: "TRANSP:OPAQUE\r\nX-MICROSOFT-CDO-BUSYSTATUS:FREE\r\n", opts: []Option{WithOutlookBusyStatus(true)}, wantBusy: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+				"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:uid@example.com\r\nDTSTART:20200211T100000Z\r\n" +
+				tt.props + "END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+			c, err := Parse(strings.NewReader(ics), nil, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := c.Events[0].IsBusy(); got != tt.wantBusy {
+				t.Errorf("IsBusy() = %v, want %v", got, tt.wantBusy)
+			}
+		})
+	}
+}
+
+func TestEvent_Matches(t *testing.T) {
+	v := NewEvent()
+	v.Summary = "Weekly Review"
+	v.Description = "Agenda\nBudget"
+	v.Categories = []string{"Work", "Finance"}
+
+	prop := NewProperty()
+	prop.Name = "LOCATION"
+	prop.Value = "Room 1\\, Building A"
+	v.Properties = append(v.Properties, prop)
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "review", want: true},
+		{query: "BUDGET", want: true},
+		{query: "finance", want: true},
+		{query: "1, building", want: true},
+		{query: "", want: true},
+		{query: "lunch", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := v.Matches(tt.query); got != tt.want {
+				t.Errorf("Matches(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+
+	if NewEvent().Matches("review") {
+		t.Error("Matches() = true on an empty event, want false")
+	}
+}
+
+func TestEvent_ContentHash(t *testing.T) {
+	event := func(props string) *Event {
+		ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+			"BEGIN:VEVENT\r\nUID:uid@example.com\r\nDTSTART:20200211T100000Z\r\n" + props +
+			"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+		c, err := Parse(strings.NewReader(ics), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c.Events[0]
+	}
+
+	v := event("DTSTAMP:20200211T090000Z\r\nSUMMARY:Meeting\r\n")
+	hash := v.ContentHash()
+
+	if len(hash) != 64 {
+		t.Errorf("ContentHash() = %q, want a hexadecimal SHA-256", hash)
+	}
+
+	if got := event("DTSTAMP:20210101T000000Z\r\nSUMMARY:Meeting\r\n").ContentHash(); got != hash {
+		t.Errorf("ContentHash() = %q with another DTSTAMP, want %q", got, hash)
+	}
+
+	if got := v.Clone().ContentHash(); got != hash {
+		t.Errorf("ContentHash() = %q on a clone, want %q", got, hash)
+	}
+
+	for _, props := range []string{
+		"DTSTAMP:20200211T090000Z\r\nSUMMARY:Lunch\r\n",
+		"DTSTAMP:20200211T090000Z\r\nSUMMARY:Meeting\r\nX-CUSTOM:value\r\n",
+		"DTSTAMP:20200211T090000Z\r\nSUMMARY:Meeting\r\nBEGIN:VALARM\r\nACTION:DISPLAY\r\nTRIGGER:-PT15M\r\nEND:VALARM\r\n",
+	} {
+		if got := event(props).ContentHash(); got == hash {
+			t.Errorf("ContentHash() = %q for %q, want another hash", got, props)
+		}
+	}
+}
@@ -0,0 +1,867 @@
+package ical
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineOctets is the maximum length of a content line, excluding the line break
+const maxLineOctets = 75
+
+// formatter holds the state of the writer
+type formatter struct {
+	w     io.Writer
+	err   error
+	props []*Property // properties of the current component, written by flush
+
+	timezones map[string]bool // TZID of the embedded VTIMEZONE components
+	formatConfig
+}
+
+// Format writes the iCalendar representation of the calendar to w
+//
+// The components missing their UID or DTSTAMP get a generated one, see the
+// WithUIDGenerator and WithClock options.
+func Format(w io.Writer, c *Calendar, opts ...FormatOption) error {
+	f := &formatter{w: w, formatConfig: defaultFormatConfig()}
+
+	for _, opt := range opts {
+		opt(&f.formatConfig)
+	}
+
+	f.formatCalendar(c)
+	return f.err
+}
+
+// writeLine writes a raw content line followed by the line ending, CRLF
+// by default
+func (f *formatter) writeLine(line string) {
+	if f.err != nil {
+		return
+	}
+	// the folded lines use the chosen line ending too
+	if f.lineEnding != crlf {
+		line = strings.ReplaceAll(line, crlf, f.lineEnding)
+	}
+
+	_, f.err = io.WriteString(f.w, line+f.lineEnding)
+}
+
+// writeProperty queues the property of the current component
+func (f *formatter) writeProperty(prop *Property) {
+	f.props = append(f.props, prop)
+}
+
+// flush writes a content line for each queued property, sorted
+// according to order. Properties missing from order come last, in the
+// order they were queued.
+func (f *formatter) flush(order []string) {
+	rank := func(name string) int {
+		for i, n := range order {
+			if n == name {
+				return i
+			}
+		}
+		return len(order)
+	}
+
+	sort.SliceStable(f.props, func(i, j int) bool {
+		return rank(f.props[i].Name) < rank(f.props[j].Name)
+	})
+
+	for _, prop := range f.props {
+		line := formatProperty(prop)
+
+		if f.folding {
+			line = fold(line)
+		}
+
+		f.writeLine(line)
+	}
+
+	f.props = f.props[:0]
+}
+
+// writeText writes a TEXT property, escaping its value
+func (f *formatter) writeText(name string, value string, properties []*Property) {
+	if value == "" {
+		return
+	}
+	prop := newPropertyFrom(name, properties)
+	prop.Value = escapeText(value)
+	f.writeProperty(prop)
+}
+
+// writeMirror writes a TEXT property duplicating another one for the
+// clients which don't know it, the minimal output only keeps the parsed ones
+func (f *formatter) writeMirror(name string, value string, properties []*Property) {
+	if f.minimal && !hasProperty(name, properties) {
+		return
+	}
+	f.writeText(name, value, properties)
+}
+
+// writeTexts writes a repeatable TEXT property, one line per value
+func (f *formatter) writeTexts(name string, values []string, properties []*Property) {
+	parsed := findProperties(name, properties)
+
+	for i, value := range values {
+		prop := NewProperty()
+		prop.Name = name
+
+		if i < len(parsed) {
+			for key, param := range parsed[i].Params {
+				prop.Params[key] = param
+			}
+		}
+
+		prop.Value = escapeText(value)
+		f.writeProperty(prop)
+	}
+}
+
+// writeTextList writes a list of TEXT values as a single comma separated
+// property
+func (f *formatter) writeTextList(name string, values []string, properties []*Property) {
+	if len(values) == 0 {
+		return
+	}
+
+	escaped := make([]string, len(values))
+
+	for i, value := range values {
+		escaped[i] = escapeText(value)
+	}
+
+	prop := newPropertyFrom(name, properties)
+	prop.Value = strings.Join(escaped, ",")
+	f.writeProperty(prop)
+}
+
+// writeValue writes a property whose value needs no escaping
+func (f *formatter) writeValue(name string, value string, properties []*Property) {
+	if value == "" {
+		return
+	}
+	prop := newPropertyFrom(name, properties)
+	prop.Value = value
+	f.writeProperty(prop)
+}
+
+// writeDate writes a DATE or DATE-TIME property
+func (f *formatter) writeDate(name string, t time.Time, allDay bool, properties []*Property) {
+	if t.IsZero() {
+		return
+	}
+
+	orig := findProperty(name, properties)
+	tzid, zoned := "", false
+
+	if orig != nil {
+		tzid, zoned = orig.ParamValue("TZID")
+	}
+
+	// A TZID defined by an embedded VTIMEZONE doesn't resolve to a
+	// time.Location, keep the parsed property as long as the time is unchanged
+	if zoned && f.timezones[tzid] {
+		if parsed, err := parseDate(orig, t.Location(), true); err == nil && parsed.Equal(t) {
+			f.writeProperty(orig)
+			return
+		}
+	}
+
+	prop := formatDate(name, t, allDay)
+
+	// keep the TZID as spelled by the parsed property, "/Europe/Paris" for
+	// instance, as long as it resolves to the location of t
+	if zoned && prop.Params["TZID"] != nil {
+		if loc, err := loadLocation(tzid, true); err == nil && loc.String() == t.Location().String() {
+			prop.Params["TZID"] = &Param{Values: []string{tzid}}
+		}
+	}
+
+	f.writeProperty(prop)
+}
+
+// eventEnd returns the exclusive end of the event v. The end of an all-day
+// event is moved to the day after when it is an inclusive last day, see the
+// WithInclusiveEndDate option, and always ends after its start day.
+func (f *formatter) eventEnd(v *Event) time.Time {
+	if !v.AllDay || v.EndDate.IsZero() {
+		return v.EndDate
+	}
+
+	// a parsed DTEND is already exclusive, keep it as long as the end is unchanged
+	if orig := findProperty("DTEND", v.Properties); orig != nil {
+		if parsed, err := parseDate(orig, v.EndDate.Location(), true); err == nil && parsed.Equal(v.EndDate) {
+			return v.EndDate
+		}
+	}
+
+	end := v.EndDate
+
+	if f.inclusiveEndDate {
+		end = end.AddDate(0, 0, 1)
+	}
+
+	if !floatingDate(end).After(floatingDate(v.StartDate)) {
+		end = v.StartDate.AddDate(0, 0, 1)
+	}
+
+	return end
+}
+
+// writeUID writes the UID property, generating one when uid is empty
+func (f *formatter) writeUID(uid string, properties []*Property) {
+	if uid == "" {
+		uid = f.uid()
+	}
+	f.writeValue("UID", uid, properties)
+}
+
+// writeTimestamp writes the DTSTAMP property, stamping the current time
+// when t is zero
+func (f *formatter) writeTimestamp(t time.Time) {
+	if t.IsZero() {
+		t = f.clock()
+	}
+	f.writeUTCTime("DTSTAMP", t)
+}
+
+// writeUTCTime writes a timestamp property, always a UTC date-time
+func (f *formatter) writeUTCTime(name string, t time.Time) {
+	if t.IsZero() {
+		return
+	}
+
+	prop := NewProperty()
+	prop.Name = name
+	prop.Value = formatUTCTime(t)
+	f.writeProperty(prop)
+}
+
+// writeAttachments writes an ATTACH property per attachment, keeping the
+// params of the parsed ATTACH properties of the component
+func (f *formatter) writeAttachments(attachments []*Attachment, properties []*Property) {
+	parsed := findProperties("ATTACH", properties)
+
+	for i, attachment := range attachments {
+		f.writeProperty(formatAttachment("ATTACH", attachment, nthProperty(parsed, i), nil))
+	}
+}
+
+// writeImages writes an IMAGE property per image, keeping the params of the
+// parsed IMAGE properties of the component
+func (f *formatter) writeImages(images []*Image, properties []*Property) {
+	parsed := findProperties("IMAGE", properties)
+
+	for i, image := range images {
+		params := map[string][]string{"DISPLAY": image.Display}
+
+		if image.URI != "" {
+			params["VALUE"] = []string{"URI"}
+		}
+
+		f.writeProperty(formatAttachment("IMAGE", &image.Attachment, nthProperty(parsed, i), params))
+	}
+}
+
+// nthProperty returns the i-th property of a list, nil when it is shorter,
+// to pair the parsed properties with the fields they were read into
+func nthProperty(properties []*Property, i int) *Property {
+	if i < len(properties) {
+		return properties[i]
+	}
+	return nil
+}
+
+// writeExtra writes the properties which are not represented by a field
+func (f *formatter) writeExtra(properties []*Property, known map[string]bool) {
+	for _, prop := range properties {
+		if !known[prop.Name] {
+			f.writeProperty(prop)
+		}
+	}
+}
+
+var (
+	calendarFields = map[string]bool{"PRODID": true, "VERSION": true, "CALSCALE": true, "METHOD": true, "NAME": true, "X-WR-CALNAME": true, "DESCRIPTION": true, "X-WR-CALDESC": true, "CATEGORIES": true, "COLOR": true, "IMAGE": true}
+	eventFields    = map[string]bool{"UID": true, "DTSTAMP": true, "DTSTART": true, "DTEND": true, "DURATION": true, "SUMMARY": true, "DESCRIPTION": true, "CONTACT": true, "CATEGORIES": true, "COLOR": true, "GEO": true, "STATUS": true, "SEQUENCE": true, "LAST-MODIFIED": true, "TRANSP": true, "ATTACH": true, "CONFERENCE": true, "ORGANIZER": true, "ATTENDEE": true, "IMAGE": true}
+	todoFields     = map[string]bool{"UID": true, "DTSTAMP": true, "DTSTART": true, "DUE": true, "COMPLETED": true, "SUMMARY": true, "DESCRIPTION": true, "PERCENT-COMPLETE": true, "STATUS": true}
+	journalFields  = map[string]bool{"UID": true, "DTSTAMP": true, "DTSTART": true, "SUMMARY": true, "DESCRIPTION": true, "STATUS": true}
+	alarmFields    = map[string]bool{"ACTION": true, "TRIGGER": true, "ATTACH": true}
+	timezoneFields = map[string]bool{"TZID": true}
+	freeBusyFields = map[string]bool{"UID": true, "DTSTAMP": true, "DTSTART": true, "DTEND": true, "FREEBUSY": true}
+)
+
+// eventOrder is the conventional order of the VEVENT properties
+var eventOrder = []string{
+	"UID", "DTSTAMP", "DTSTART", "DTEND", "DURATION",
+	"RRULE", "RDATE", "EXRULE", "EXDATE", "RECURRENCE-ID",
+	"SUMMARY", "DESCRIPTION", "LOCATION", "GEO",
+	"ORGANIZER", "ATTENDEE", "CONTACT",
+	"CATEGORIES", "CLASS", "PRIORITY", "STATUS", "TRANSP",
+	"SEQUENCE", "CREATED", "LAST-MODIFIED",
+	"URL", "COMMENT", "RELATED-TO", "RESOURCES", "REQUEST-STATUS",
+	"ATTACH", "CONFERENCE", "IMAGE", "COLOR",
+}
+
+// alarmOrder is the conventional order of the VALARM properties, the
+// ACTION and the TRIGGER first as Apple and Google write them
+var alarmOrder = []string{
+	"ACTION", "TRIGGER", "DURATION", "REPEAT",
+	"DESCRIPTION", "SUMMARY", "ATTENDEE", "ATTACH",
+}
+
+// calendarOrder is the canonical order of the VCALENDAR properties, some
+// importers require VERSION to come first
+var calendarOrder = []string{"PRODID", "VERSION", "CALSCALE", "METHOD"}
+
+// formatCalendar writes a VCALENDAR component
+func (f *formatter) formatCalendar(c *Calendar) {
+	f.writeLine(beginVCalendar)
+	f.writeValue("PRODID", c.Prodid, c.Properties)
+	f.writeValue("VERSION", c.Version, c.Properties)
+
+	// GREGORIAN is the default calendar scale, no need to write it
+	if c.Calscale != "GREGORIAN" {
+		f.writeValue("CALSCALE", c.Calscale, c.Properties)
+	}
+
+	f.writeValue("METHOD", c.Method, c.Properties)
+	f.writeText("NAME", c.Name, c.Properties)
+	f.writeMirror("X-WR-CALNAME", c.Name, c.Properties)
+	f.writeText("DESCRIPTION", c.Description, c.Properties)
+	f.writeMirror("X-WR-CALDESC", c.Description, c.Properties)
+	f.writeTextList("CATEGORIES", c.Categories, c.Properties)
+	f.writeValue("COLOR", c.Color, c.Properties)
+	f.writeImages(c.Images, c.Properties)
+	f.writeExtra(c.Properties, calendarFields)
+	f.flush(calendarOrder)
+
+	referenced := referencedTimezones(c)
+	f.timezones = make(map[string]bool, len(c.Timezones))
+
+	for _, z := range c.Timezones {
+		f.timezones[z.TZID] = true
+
+		if referenced[z.TZID] {
+			f.formatTimezone(z)
+		}
+	}
+
+	for _, v := range c.Events {
+		f.formatEvent(v)
+	}
+
+	for _, t := range c.Todos {
+		f.formatTodo(t)
+	}
+
+	for _, j := range c.Journals {
+		f.formatJournal(j)
+	}
+
+	for _, fb := range c.FreeBusys {
+		f.formatFreeBusy(fb)
+	}
+
+	for _, comp := range c.Components {
+		if handler := componentHandler(comp.Name); handler != nil {
+			if err := handler.FormatComponent(comp); err != nil {
+				f.err = fmt.Errorf("error in %s: %w", comp.Name, err)
+				return
+			}
+		}
+
+		f.formatComponent(comp)
+	}
+
+	f.writeLine(endVCalendar)
+}
+
+// formatComponent writes a component the package doesn't model, with its
+// properties as is
+func (f *formatter) formatComponent(comp *Component) {
+	f.writeLine("BEGIN:" + comp.Name)
+
+	for _, prop := range comp.Properties {
+		f.writeProperty(prop)
+	}
+
+	f.flush(nil)
+
+	for _, sub := range comp.Components {
+		f.formatComponent(sub)
+	}
+
+	f.writeLine("END:" + comp.Name)
+}
+
+// formatEvent writes a VEVENT component
+func (f *formatter) formatEvent(v *Event) {
+	f.writeLine(beginVEvent)
+	f.writeUID(v.UID, v.Properties)
+	f.writeTimestamp(v.Timestamp)
+	f.writeDate("DTSTART", v.StartDate, v.AllDay, v.Properties)
+
+	// an event defined by a duration keeps it as long as the end date was not edited
+	if v.Duration != 0 && (v.EndDate.IsZero() || v.EndDate.Equal(v.StartDate.Add(v.Duration))) {
+		f.writeValue("DURATION", formatDuration(v.Duration), v.Properties)
+	} else {
+		f.writeDate("DTEND", f.eventEnd(v), v.AllDay, v.Properties)
+	}
+
+	f.writeText("SUMMARY", v.Summary, v.Properties)
+	f.writeText("DESCRIPTION", v.Description, v.Properties)
+	f.writeTexts("CONTACT", v.Contacts, v.Properties)
+	f.writeTextList("CATEGORIES", v.Categories, v.Properties)
+	f.writeValue("COLOR", v.Color, v.Properties)
+
+	if v.Geo != nil {
+		if err := v.Geo.Validate(); err != nil && f.err == nil {
+			f.err = err
+		}
+		f.writeValue("GEO", formatGeo(v.Geo), v.Properties)
+	}
+
+	f.writeValue("STATUS", string(v.Status), v.Properties)
+
+	if v.Sequence > 0 || hasProperty("SEQUENCE", v.Properties) {
+		f.writeValue("SEQUENCE", strconv.Itoa(v.Sequence), v.Properties)
+	}
+
+	f.writeUTCTime("LAST-MODIFIED", v.Modified)
+
+	// OPAQUE is the default transparency, no need to write it
+	if v.Transparency != TransparencyOpaque {
+		f.writeValue("TRANSP", string(v.Transparency), v.Properties)
+	}
+	f.writeAttachments(v.Attachments, v.Properties)
+
+	conferences := findProperties("CONFERENCE", v.Properties)
+
+	for i, conference := range v.Conferences {
+		f.writeProperty(formatConference(conference, nthProperty(conferences, i)))
+	}
+
+	if v.Organizer != nil {
+		f.writeProperty(formatOrganizer(v.Organizer, findProperty("ORGANIZER", v.Properties)))
+	}
+
+	attendees := findProperties("ATTENDEE", v.Properties)
+
+	for i, attendee := range v.Attendees {
+		f.writeProperty(formatAttendee(attendee, nthProperty(attendees, i)))
+	}
+
+	f.writeImages(v.Images, v.Properties)
+
+	f.writeExtra(v.Properties, eventFields)
+	f.flush(eventOrder)
+
+	for _, a := range v.Alarms {
+		f.formatAlarm(a)
+	}
+
+	f.writeLine(endVEvent)
+}
+
+// formatTodo writes a VTODO component
+func (f *formatter) formatTodo(t *Todo) {
+	f.writeLine(beginVTodo)
+	f.writeUID(t.UID, t.Properties)
+	f.writeTimestamp(t.Timestamp)
+	f.writeDate("DTSTART", t.StartDate, isDateProperty(findProperty("DTSTART", t.Properties)), t.Properties)
+	f.writeDate("DUE", t.Due, isDateProperty(findProperty("DUE", t.Properties)), t.Properties)
+	f.writeUTCTime("COMPLETED", t.Completed)
+	f.writeText("SUMMARY", t.Summary, t.Properties)
+	f.writeText("DESCRIPTION", t.Description, t.Properties)
+
+	f.writeValue("STATUS", string(t.Status), t.Properties)
+
+	if t.PercentComplete > 0 || hasProperty("PERCENT-COMPLETE", t.Properties) {
+		f.writeValue("PERCENT-COMPLETE", strconv.Itoa(t.PercentComplete), t.Properties)
+	}
+
+	f.writeExtra(t.Properties, todoFields)
+	f.flush(nil)
+
+	for _, a := range t.Alarms {
+		f.formatAlarm(a)
+	}
+
+	f.writeLine(endVTodo)
+}
+
+// formatJournal writes a VJOURNAL component
+func (f *formatter) formatJournal(j *Journal) {
+	f.writeLine(beginVJournal)
+	f.writeUID(j.UID, j.Properties)
+	f.writeTimestamp(j.Timestamp)
+	f.writeDate("DTSTART", j.StartDate, isDateProperty(findProperty("DTSTART", j.Properties)), j.Properties)
+	f.writeText("SUMMARY", j.Summary, j.Properties)
+	f.writeText("DESCRIPTION", j.Description, j.Properties)
+	f.writeValue("STATUS", string(j.Status), j.Properties)
+	f.writeExtra(j.Properties, journalFields)
+	f.flush(nil)
+	f.writeLine(endVJournal)
+}
+
+// formatFreeBusy writes a VFREEBUSY component, the consecutive periods
+// sharing a type are written on the same FREEBUSY line
+func (f *formatter) formatFreeBusy(fb *FreeBusy) {
+	f.writeLine(beginVFreeBusy)
+	f.writeUID(fb.UID, fb.Properties)
+	f.writeTimestamp(fb.Timestamp)
+	f.writeDate("DTSTART", fb.StartDate, false, fb.Properties)
+	f.writeDate("DTEND", fb.EndDate, false, fb.Properties)
+
+	for i := 0; i < len(fb.Periods); {
+		prop := NewProperty()
+		prop.Name = "FREEBUSY"
+
+		// BUSY is the default type, no need to write it
+		if fbtype := fb.Periods[i].Type; fbtype != FreeBusyBusy {
+			prop.Params["FBTYPE"] = &Param{Values: []string{string(fbtype)}}
+		}
+
+		values := make([]string, 0)
+
+		for j := i; j < len(fb.Periods) && fb.Periods[j].Type == fb.Periods[i].Type; j++ {
+			period := fb.Periods[j]
+			values = append(values, formatUTCTime(period.Start)+"/"+formatUTCTime(period.End))
+		}
+
+		prop.Value = strings.Join(values, ",")
+		f.writeProperty(prop)
+		i += len(values)
+	}
+
+	f.writeExtra(fb.Properties, freeBusyFields)
+	f.flush(nil)
+	f.writeLine(endVFreeBusy)
+}
+
+// formatTimezone writes a VTIMEZONE component and its rules
+func (f *formatter) formatTimezone(z *Timezone) {
+	f.writeLine(beginVTimezone)
+	f.writeValue("TZID", z.TZID, z.Properties)
+	f.writeExtra(z.Properties, timezoneFields)
+	f.flush(nil)
+
+	for _, r := range z.Standard {
+		f.writeLine(beginStandard)
+		f.writeExtra(r.Properties, nil)
+		f.flush(nil)
+		f.writeLine(endStandard)
+	}
+
+	for _, r := range z.Daylight {
+		f.writeLine(beginDaylight)
+		f.writeExtra(r.Properties, nil)
+		f.flush(nil)
+		f.writeLine(endDaylight)
+	}
+
+	f.writeLine(endVTimezone)
+}
+
+// referencedTimezones lists the TZID used by the events, todos and
+// journals, either through a TZID param or the location of their times
+func referencedTimezones(c *Calendar) map[string]bool {
+	referenced := make(map[string]bool)
+
+	reference := func(properties []*Property, times ...time.Time) {
+		for _, prop := range properties {
+			if tzid, ok := prop.ParamValue("TZID"); ok {
+				referenced[tzid] = true
+			}
+		}
+
+		for _, t := range times {
+			if !t.IsZero() && t.Location() != time.UTC && t.Location() != time.Local {
+				referenced[t.Location().String()] = true
+			}
+		}
+	}
+
+	for _, v := range c.Events {
+		reference(v.Properties, v.StartDate, v.EndDate)
+	}
+
+	for _, t := range c.Todos {
+		reference(t.Properties, t.StartDate, t.Due)
+	}
+
+	for _, j := range c.Journals {
+		reference(j.Properties, j.StartDate)
+	}
+
+	return referenced
+}
+
+// formatAlarm writes a VALARM component
+func (f *formatter) formatAlarm(a *Alarm) {
+	f.writeLine(beginValarm)
+	f.writeValue("ACTION", a.Action, a.Properties)
+
+	if !a.TriggerDate.IsZero() {
+		prop := NewProperty()
+		prop.Name = "TRIGGER"
+		prop.Params["VALUE"] = &Param{Values: []string{"DATE-TIME"}}
+		prop.Value = formatUTCTime(a.TriggerDate)
+		f.writeProperty(prop)
+	} else {
+		f.writeValue("TRIGGER", a.Trigger, a.Properties)
+	}
+
+	f.writeAttachments(a.Attachments, a.Properties)
+	f.writeExtra(a.Properties, alarmFields)
+	f.flush(alarmOrder)
+	f.writeLine(endVAlarm)
+}
+
+// newPropertyFrom creates a property keeping the params of the parsed one, if any
+func newPropertyFrom(name string, properties []*Property) *Property {
+	prop := NewProperty()
+	prop.Name = name
+
+	if orig := findProperty(name, properties); orig != nil {
+		for key, param := range orig.Params {
+			prop.Params[key] = param
+		}
+	}
+
+	return prop
+}
+
+// formatUTCTime formats t as a UTC date-time, fractional seconds are
+// dropped since iCalendar doesn't support them
+func formatUTCTime(t time.Time) string {
+	return t.UTC().Format(dateTimeLayoutUTC)
+}
+
+// formatDate creates a DATE or DATE-TIME property from a time.Time
+// UTC times use the "Z" suffix, times in the local location are floating
+// and any other location is referenced through the TZID param
+func formatDate(name string, t time.Time, allDay bool) *Property {
+	prop := NewProperty()
+	prop.Name = name
+
+	switch {
+	case allDay:
+		prop.Params["VALUE"] = &Param{Values: []string{"DATE"}}
+		prop.Value = t.Format(dateLayout)
+	case t.Location() == time.UTC:
+		prop.Value = formatUTCTime(t)
+	case t.Location() == time.Local:
+		prop.Value = t.Format(dateTimeLayoutLocalized)
+	default:
+		prop.Params["TZID"] = &Param{Values: []string{t.Location().String()}}
+		prop.Value = t.Format(dateTimeLayoutLocalized)
+	}
+
+	return prop
+}
+
+// formatAttachment creates an ATTACH like property from an Attachment,
+// inlining the data as BASE64 binary when there is no URI. The params of
+// the parsed property orig, FILENAME for instance, are kept unless
+// overridden by the fields or the given params.
+func formatAttachment(name string, attachment *Attachment, orig *Property, params map[string][]string) *Property {
+	overrides := map[string][]string{
+		"FMTTYPE":  {attachment.MimeType},
+		"ENCODING": nil,
+		"VALUE":    nil,
+	}
+
+	value := attachment.URI
+
+	if value == "" {
+		overrides["ENCODING"] = []string{"BASE64"}
+		overrides["VALUE"] = []string{"BINARY"}
+		value = base64.StdEncoding.EncodeToString(attachment.Data)
+	}
+
+	for key, values := range params {
+		overrides[key] = values
+	}
+
+	return formatWithParams(name, value, orig, overrides)
+}
+
+// formatConference creates a CONFERENCE property from a Conference, keeping
+// the params of the parsed property orig which have no field
+func formatConference(conference Conference, orig *Property) *Property {
+	return formatWithParams("CONFERENCE", conference.URI, orig, map[string][]string{
+		"VALUE":   {"URI"},
+		"FEATURE": conference.Features,
+		"LABEL":   {conference.Label},
+	})
+}
+
+// formatOrganizer creates an ORGANIZER property from an Organizer, keeping
+// the params of the parsed property orig which have no field
+func formatOrganizer(organizer *Organizer, orig *Property) *Property {
+	return formatWithParams("ORGANIZER", organizer.Address, orig, map[string][]string{
+		"CN":      {organizer.CommonName},
+		"DIR":     {organizer.Dir},
+		"SENT-BY": {organizer.SentBy},
+	})
+}
+
+// formatAttendee creates an ATTENDEE property from an Attendee, keeping
+// the params of the parsed property orig which have no field
+func formatAttendee(attendee *Attendee, orig *Property) *Property {
+	cutype := string(attendee.CUType)
+
+	// INDIVIDUAL is the default type, no need to write it unless it was
+	if attendee.CUType == CUTypeIndividual && (orig == nil || orig.Params["CUTYPE"] == nil) {
+		cutype = ""
+	}
+
+	return formatWithParams("ATTENDEE", attendee.Address, orig, map[string][]string{
+		"CN":             {attendee.CommonName},
+		"DIR":            {attendee.Dir},
+		"CUTYPE":         {cutype},
+		"DELEGATED-FROM": attendee.DelegatedFrom,
+		"DELEGATED-TO":   attendee.DelegatedTo,
+		"MEMBER":         attendee.Member,
+	})
+}
+
+// formatWithParams creates a property from its modeled value, with the
+// params of the parsed property orig overridden by the given ones, an empty
+// one removing the param. The values containing a separator, such as a CN
+// like "Doe, John", are quoted by formatProperty.
+func formatWithParams(name, value string, orig *Property, params map[string][]string) *Property {
+	prop := NewProperty()
+	prop.Name = name
+
+	if orig != nil {
+		for key, param := range orig.Params {
+			prop.Params[key] = param
+		}
+	}
+
+	for key, values := range params {
+		delete(prop.Params, key)
+
+		if len(values) > 0 && values[0] != "" {
+			prop.Params[key] = &Param{Values: values}
+		}
+	}
+
+	prop.Value = value
+
+	return prop
+}
+
+// formatProperty serializes a property into an unfolded content line
+//
+// contentline = name *(";" param ) ":" value CRLF
+// param       = param-name "=" param-value *("," param-value)
+func formatProperty(prop *Property) string {
+	var b strings.Builder
+
+	b.WriteString(prop.Name)
+
+	names := make([]string, 0, len(prop.Params))
+	for name := range prop.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if prop.Params[name] == nil {
+			continue
+		}
+
+		for _, values := range prop.Params[name].occurrences() {
+			b.WriteString(";")
+			b.WriteString(name)
+			b.WriteString("=")
+
+			for i, value := range values {
+				if i > 0 {
+					b.WriteString(",")
+				}
+				b.WriteString(formatParamValue(value))
+			}
+		}
+	}
+
+	b.WriteString(":")
+	b.WriteString(prop.Value)
+
+	return b.String()
+}
+
+// formatParamValue quotes a param value when it contains a separator or a
+// character which needs the RFC 6868 caret escaping
+func formatParamValue(value string) string {
+	if strings.ContainsAny(value, ",;:\n\"") {
+		return `"` + paramEscaper.Replace(value) + `"`
+	}
+	return paramEscaper.Replace(value)
+}
+
+var paramEscaper = strings.NewReplacer(
+	"^", "^^",
+	"\r\n", "^n",
+	"\n", "^n",
+	`"`, "^'",
+)
+
+// escapeText escapes a TEXT value
+//
+// ESCAPED-CHAR = ("\\" / "\;" / "\," / "\N" / "\n")
+func escapeText(value string) string {
+	return textEscaper.Replace(value)
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`;`, `\;`,
+	`,`, `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+// fold splits a content line longer than 75 octets into multiple lines,
+// each continuation line starting with a single space. Lines are never
+// split in the middle of a UTF-8 sequence.
+func fold(line string) string {
+	if len(line) <= maxLineOctets {
+		return line
+	}
+
+	var b strings.Builder
+	limit := maxLineOctets
+
+	for len(line) > limit {
+		i := limit
+		for i > 0 && !utf8.RuneStart(line[i]) {
+			i--
+		}
+
+		b.WriteString(line[:i])
+		b.WriteString(crlf)
+		b.WriteString(" ")
+		line = line[i:]
+
+		// the leading space counts in the continuation line length
+		limit = maxLineOctets - 1
+	}
+
+	b.WriteString(line)
+
+	return b.String()
+}
@@ -0,0 +1,1010 @@
+package ical
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	c := NewCalendar()
+	c.Prodid = "-//xyz Corp//NONSGML PDA Calendar Version 1.0//EN"
+
+	v := NewEvent()
+	v.UID = "uid1@example.com"
+	v.Timestamp = time.Date(1996, time.July, 4, 12, 0, 0, 0, time.UTC)
+	v.StartDate = time.Date(1996, time.September, 18, 14, 30, 0, 0, time.UTC)
+	v.EndDate = time.Date(1996, time.September, 20, 22, 0, 0, 0, time.UTC)
+	v.Summary = "Networld+Interop Conference"
+	c.Events = append(c.Events, v)
+
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"PRODID:-//xyz Corp//NONSGML PDA Calendar Version 1.0//EN",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"UID:uid1@example.com",
+		"DTSTAMP:19960704T120000Z",
+		"DTSTART:19960918T143000Z",
+		"DTEND:19960920T220000Z",
+		"SUMMARY:Networld+Interop Conference",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := buf.String(); got != want {
+		t.Errorf("Format() = %q, want %q", got, want)
+	}
+}
+
+func TestFormat_newCalendar(t *testing.T) {
+	start := time.Date(2020, time.February, 11, 10, 0, 0, 0, time.UTC)
+
+	c := NewCalendar()
+	c.AddEvent(NewTimedEvent("uid@example.com", "Meeting", start, start.Add(time.Hour)))
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := Parse(&buf, nil, WithStrict(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.Prodid != DefaultProdID || len(parsed.Events) != 1 {
+		t.Errorf("Parse() = %+v, want the default PRODID and the event", parsed)
+	}
+}
+
+func TestFormat_roundTrip(t *testing.T) {
+	description := "Networld+Interop Conference and Exhibit\n" +
+		"Atlanta World Congress Center, Atlanta; Georgia\n\n" +
+		"Second paragraph with a \\ backslash and long enough content to be folded several times by the formatter, café included."
+
+	c := NewCalendar()
+	c.SetProduct("-//xyz Corp//NONSGML PDA Calendar Version 1.0//EN", "2.0")
+
+	v := NewEvent()
+	v.UID = "uid1@example.com"
+	v.Timestamp = time.Date(1996, time.July, 4, 12, 0, 0, 0, time.UTC)
+	v.StartDate = time.Date(1996, time.September, 18, 14, 30, 0, 0, time.UTC)
+	v.Summary = "Multi, paragraph; description"
+	v.Description = description
+	v.Contacts = []string{"Jim Dolittle, ABC Industries, +1-919-555-1234", "Joe Smith"}
+	v.Categories = []string{"Work, home", "Meeting"}
+	c.Events = append(c.Events, v)
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line exceeds %d octets: %q", maxLineOctets, line)
+		}
+	}
+
+	parsed, err := Parse(&buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := parsed.Events[0].Description; got != description {
+		t.Errorf("Description = %q, want %q", got, description)
+	}
+
+	if got := parsed.Events[0].Summary; got != v.Summary {
+		t.Errorf("Summary = %q, want %q", got, v.Summary)
+	}
+
+	if got := parsed.Events[0].Contacts; !reflect.DeepEqual(got, v.Contacts) {
+		t.Errorf("Contacts = %q, want %q", got, v.Contacts)
+	}
+
+	if got := parsed.Events[0].Categories; !reflect.DeepEqual(got, v.Categories) {
+		t.Errorf("Categories = %q, want %q", got, v.Categories)
+	}
+}
+
+func Test_fold(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{
+			name: "Short line",
+			line: "SUMMARY:short",
+			want: "SUMMARY:short",
+		},
+		{
+			name: "Long line",
+			line: "DESCRIPTION:" + strings.Repeat("a", 70),
+			want: "DESCRIPTION:" + strings.Repeat("a", 63) + "\r\n " + strings.Repeat("a", 7),
+		},
+		{
+			name: "Multibyte character on the boundary",
+			line: "DESCRIPTION:" + strings.Repeat("a", 62) + "é",
+			want: "DESCRIPTION:" + strings.Repeat("a", 62) + "\r\n é",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fold(tt.line); got != tt.want {
+				t.Errorf("fold() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat_todo(t *testing.T) {
+	c := NewCalendar()
+	c.SetProduct("-//ABC Corporation//NONSGML My Product//EN", "2.0")
+
+	todo := NewTodo()
+	todo.UID = "uid4@example.com"
+	todo.Timestamp = time.Date(1998, time.January, 30, 13, 45, 0, 0, time.UTC)
+	todo.Due = time.Date(1998, time.April, 15, 0, 0, 0, 0, time.UTC)
+	todo.Completed = time.Date(1998, time.April, 7, 10, 0, 0, 0, time.UTC)
+	todo.Summary = "Submit Income Taxes"
+	todo.PercentComplete = 100
+	c.Todos = append(c.Todos, todo)
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := Parse(&buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := parsed.Todos[0]
+
+	if !got.Due.Equal(todo.Due) {
+		t.Errorf("Due = %v, want %v", got.Due, todo.Due)
+	}
+
+	if !got.Completed.Equal(todo.Completed) {
+		t.Errorf("Completed = %v, want %v", got.Completed, todo.Completed)
+	}
+
+	if got.PercentComplete != todo.PercentComplete {
+		t.Errorf("PercentComplete = %d, want %d", got.PercentComplete, todo.PercentComplete)
+	}
+}
+
+func TestFormat_attachments(t *testing.T) {
+	c := NewCalendar()
+	c.SetProduct("-//xyz Corp//NONSGML PDA Calendar Version 1.0//EN", "2.0")
+
+	v := NewEvent()
+	v.UID = "uid1@example.com"
+	v.Timestamp = time.Date(1996, time.July, 4, 12, 0, 0, 0, time.UTC)
+	v.StartDate = time.Date(1996, time.September, 18, 14, 30, 0, 0, time.UTC)
+	v.Attachments = []*Attachment{
+		{URI: "ftp://example.com/pub/reports/r-960812.ps", MimeType: "application/postscript"},
+		{Data: []byte("The quick brown fox jumps over the lazy dog."), MimeType: "text/plain"},
+	}
+	c.Events = append(c.Events, v)
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := Parse(&buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := parsed.Events[0].Attachments; !reflect.DeepEqual(got, v.Attachments) {
+		t.Errorf("Attachments = %v, want %v", got, v.Attachments)
+	}
+}
+
+func TestFormat_attachmentParams(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"IMAGE;VALUE=URI;X-SOURCE=cdn;FMTTYPE=image/png:http://example.com/images/party.png\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:uid@example.com\r\nDTSTART:20200211T100000Z\r\n" +
+		"ATTACH;FILENAME=report.pdf;FMTTYPE=application/pdf;X-APPLE-FILESIZE=1234:https://example.com/report\r\n" +
+		"ATTACH;FILENAME=notes.txt:https://example.com/notes\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Events[0].Attachments[1].MimeType = "text/plain"
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c, WithFolding(false)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"\r\nIMAGE;FMTTYPE=image/png;VALUE=URI;X-SOURCE=cdn:http://example.com/images/party.png\r\n",
+		"\r\nATTACH;FILENAME=report.pdf;FMTTYPE=application/pdf;X-APPLE-FILESIZE=1234:https://example.com/report\r\n",
+		"\r\nATTACH;FILENAME=notes.txt;FMTTYPE=text/plain:https://example.com/notes\r\n",
+	} {
+		if got := buf.String(); !strings.Contains(got, want) {
+			t.Errorf("Format() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestFormat_conferences(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:uid@example.com\r\nDTSTART:20200211T100000Z\r\n" +
+		"CONFERENCE;VALUE=URI;FEATURE=PHONE,MODERATOR;LABEL=Moderator dial-in:tel:+1-412-555-0123,,,654321\r\n" +
+		"CONFERENCE;VALUE=URI;FEATURE=VIDEO;LABEL=\"Join: video\":https://chat.example.com/audio?id=123456\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Conference{
+		{URI: "tel:+1-412-555-0123,,,654321", Features: []string{"PHONE", "MODERATOR"}, Label: "Moderator dial-in"},
+		{URI: "https://chat.example.com/audio?id=123456", Features: []string{"VIDEO"}, Label: "Join: video"},
+	}
+
+	if got := c.Events[0].Conferences; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Conferences = %v, want %v", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := Parse(&buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := parsed.Events[0].Conferences; !reflect.DeepEqual(got, want) {
+		t.Errorf("Conferences = %v, want %v after round trip", got, want)
+	}
+}
+
+func TestFormat_conferenceParams(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:uid@example.com\r\nDTSTART:20200211T100000Z\r\n" +
+		"CONFERENCE;VALUE=URI;FEATURE=AUDIO;LABEL=Dial-in;LANGUAGE=fr;X-PIN=1234:tel:+33-1-55-55-01-23\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Events[0].Conferences[0].Label = "Audio"
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c, WithFolding(false)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "\r\nCONFERENCE;FEATURE=AUDIO;LABEL=Audio;LANGUAGE=fr;VALUE=URI;X-PIN=1234:tel:+33-1-55-55-01-23\r\n"
+
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Errorf("Format() = %q, want it to contain %q", got, want)
+	}
+}
+
+func TestFormat_eventOrder(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nX-B:2\r\nLOCATION:Room 1\r\nSUMMARY:Meeting\r\nX-A:1\r\nDTSTART:20200211T100000Z\r\n" +
+		"ORGANIZER:mailto:a@example.com\r\nUID:uid@example.com\r\nDTSTAMP:20200211T090000Z\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"BEGIN:VEVENT",
+		"UID:uid@example.com",
+		"DTSTAMP:20200211T090000Z",
+		"DTSTART:20200211T100000Z",
+		"DTEND:20200212T100000Z",
+		"SUMMARY:Meeting",
+		"LOCATION:Room 1",
+		"ORGANIZER:mailto:a@example.com",
+		"X-B:2",
+		"X-A:1",
+		"END:VEVENT",
+	}, "\r\n")
+
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Errorf("Format() = %q, want it to contain %q", got, want)
+	}
+}
+
+func TestFormat_alarmOrder(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nUID:uid@example.com\r\nDTSTAMP:20200211T090000Z\r\nDTSTART:20200211T100000Z\r\n" +
+		"BEGIN:VALARM\r\nX-WR-ALARMUID:1\r\nDESCRIPTION:Reminder\r\nTRIGGER:-PT15M\r\nREPEAT:2\r\nDURATION:PT5M\r\nACTION:DISPLAY\r\nEND:VALARM\r\n" +
+		"BEGIN:VALARM\r\nTRIGGER:-PT5M\r\nACTION:AUDIO\r\nEND:VALARM\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"BEGIN:VALARM",
+		"ACTION:DISPLAY",
+		"TRIGGER:-PT15M",
+		"DURATION:PT5M",
+		"REPEAT:2",
+		"DESCRIPTION:Reminder",
+		"X-WR-ALARMUID:1",
+		"END:VALARM",
+		"BEGIN:VALARM",
+		"ACTION:AUDIO",
+		"TRIGGER:-PT5M",
+		"END:VALARM",
+	}, "\r\n")
+
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Errorf("Format() = %q, want it to contain %q", got, want)
+	}
+}
+
+func TestFormat_images(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"IMAGE;VALUE=URI;DISPLAY=BADGE;FMTTYPE=image/png:http://example.com/images/party.png\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:uid@example.com\r\nDTSTART:20200211T100000Z\r\n" +
+		"IMAGE;ENCODING=BASE64;VALUE=BINARY;DISPLAY=THUMBNAIL,BADGE;FMTTYPE=image/gif:R0lGODlhAQABAAAAACw=\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := Parse(&buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, images := range [][]*Image{c.Images, parsed.Images, c.Events[0].Images, parsed.Events[0].Images} {
+		if len(images) != 1 {
+			t.Fatalf("got %d images, want 1", len(images))
+		}
+	}
+
+	if !reflect.DeepEqual(parsed.Images, c.Images) {
+		t.Errorf("calendar Images = %+v, want %+v", parsed.Images[0], c.Images[0])
+	}
+
+	if !reflect.DeepEqual(parsed.Events[0].Images, c.Events[0].Images) {
+		t.Errorf("event Images = %+v, want %+v", parsed.Events[0].Images[0], c.Events[0].Images[0])
+	}
+
+	if got := c.Events[0].Images[0].Display; !reflect.DeepEqual(got, []string{"THUMBNAIL", "BADGE"}) {
+		t.Errorf("Display = %v, want [THUMBNAIL BADGE]", got)
+	}
+}
+
+func TestFormat_calendarOrder(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nX-WR-TIMEZONE:Europe/Paris\r\nMETHOD:PUBLISH\r\nVERSION:2.0\r\nPRODID:test\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:uid@example.com\r\nDTSTART:20200211T100000Z\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\nMETHOD:PUBLISH\r\nX-WR-TIMEZONE:Europe/Paris\r\n"
+
+	if got := buf.String(); !strings.HasPrefix(got, want) {
+		t.Errorf("Format() = %q, want prefix %q", got, want)
+	}
+}
+
+func TestFormat_timezones(t *testing.T) {
+	file, _ := os.Open("fixtures/vtimezone.ics")
+	c, err := Parse(file, nil)
+	file.Close()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+
+	for _, want := range []string{
+		"BEGIN:VTIMEZONE\r\nTZID:Custom\r\nBEGIN:STANDARD\r\nDTSTART:19671029T020000\r\n",
+		"DTSTART;TZID=Custom:20060102T100000\r\n",
+		"DTEND;TZID=Custom:20060102T110000\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Format() = %q, want it to contain %q", got, want)
+		}
+	}
+
+	if strings.Contains(got, "TZID:Unused") {
+		t.Errorf("Format() = %q, want the unreferenced timezone to be dropped", got)
+	}
+}
+
+func Test_formatParamValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "Joe Smith", want: "Joe Smith"},
+		{value: "mailto:joe@example.com", want: `"mailto:joe@example.com"`},
+		{value: `George Herman "Babe" Ruth`, want: `"George Herman ^'Babe^' Ruth"`},
+		{value: "Pittsburgh Pirates\n118 Bellerive Ave.", want: `"Pittsburgh Pirates^n118 Bellerive Ave."`},
+		{value: "a^n", want: "a^^n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got := formatParamValue(tt.value)
+			if got != tt.want {
+				t.Errorf("formatParamValue() = %q, want %q", got, tt.want)
+			}
+			if back := unescapeParamValue(strings.Trim(got, `"`)); back != tt.value {
+				t.Errorf("unescapeParamValue() = %q, want %q", back, tt.value)
+			}
+		})
+	}
+}
+
+func TestFormat_duration(t *testing.T) {
+	c := NewCalendar()
+	c.SetProduct("-//xyz Corp//NONSGML PDA Calendar Version 1.0//EN", "2.0")
+
+	v := NewEvent()
+	v.UID = "uid1@example.com"
+	v.Timestamp = time.Date(1996, time.July, 4, 12, 0, 0, 0, time.UTC)
+	v.StartDate = time.Date(1996, time.September, 18, 14, 30, 0, 0, time.UTC)
+	v.Duration = 90 * time.Minute
+	c.Events = append(c.Events, v)
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := buf.String(); !strings.Contains(got, "DURATION:PT1H30M\r\n") || strings.Contains(got, "DTEND") {
+		t.Errorf("Format() = %q, want DURATION instead of DTEND", got)
+	}
+}
+
+func TestFormat_durationEditedEnd(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:19960704T120000Z\r\nUID:uid1@example.com\r\n" +
+		"DTSTART:19960918T143000Z\r\nDURATION:PT1H30M\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Events[0].EndDate = time.Date(1996, time.September, 18, 17, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := buf.String(); !strings.Contains(got, "DTEND:19960918T170000Z\r\n") || strings.Contains(got, "DURATION") {
+		t.Errorf("Format() = %q, want DTEND instead of DURATION", got)
+	}
+}
+
+func TestFormat_geo(t *testing.T) {
+	c := NewCalendar()
+	c.SetProduct("-//xyz Corp//NONSGML PDA Calendar Version 1.0//EN", "2.0")
+
+	v := NewEvent()
+	v.UID = "uid1@example.com"
+	v.Timestamp = time.Date(1996, time.July, 4, 12, 0, 0, 0, time.UTC)
+	v.StartDate = time.Date(1996, time.September, 18, 14, 30, 0, 0, time.UTC)
+	v.Geo = &Geo{Latitude: 37.386013, Longitude: -122.0829326}
+	c.Events = append(c.Events, v)
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := buf.String(); !strings.Contains(got, "GEO:37.386013;-122.082933\r\n") {
+		t.Errorf("Format() = %q, want GEO with six decimal places", got)
+	}
+
+	v.Geo = &Geo{Latitude: 95, Longitude: 0}
+
+	if err := Format(&bytes.Buffer{}, c); err == nil {
+		t.Error("expected an error on an out of range latitude")
+	}
+}
+
+func TestFormat_attendees(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:uid@example.com\r\nDTSTART:20200211T100000Z\r\n" +
+		"ATTENDEE;ROLE=REQ-PARTICIPANT;CUTYPE=room;DELEGATED-FROM=\"mailto:iamboss@example.com\";CN=Henry\r\n" +
+		"  Cabot:mailto:hcabot@example.com\r\n" +
+		"ATTENDEE;DELEGATED-TO=\"mailto:hcabot@example.com\",\"mailto:jdoe@example.com\";MEMBER=\"mailto:DEV-GROUP@e\r\n" +
+		" xample.com\";CN=The Big Cheese;DIR=\"ldap://example.com:6666/o=ABC%20Industries,c=US???(cn=Jim%20Dolittle)\":mailto:iamboss@example.com\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*Attendee{
+		{
+			Address:       "mailto:hcabot@example.com",
+			CommonName:    "Henry Cabot",
+			CUType:        CUTypeRoom,
+			DelegatedFrom: []string{"mailto:iamboss@example.com"},
+			DelegatedTo:   []string{},
+			Member:        []string{},
+		},
+		{
+			Address:       "mailto:iamboss@example.com",
+			CommonName:    "The Big Cheese",
+			Dir:           "ldap://example.com:6666/o=ABC%20Industries,c=US???(cn=Jim%20Dolittle)",
+			CUType:        CUTypeIndividual,
+			DelegatedFrom: []string{},
+			DelegatedTo:   []string{"mailto:hcabot@example.com", "mailto:jdoe@example.com"},
+			Member:        []string{"mailto:DEV-GROUP@example.com"},
+		},
+	}
+
+	if got := c.Events[0].Attendees; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Attendees = %+v, want %+v", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := Parse(&buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := parsed.Events[0].Attendees; !reflect.DeepEqual(got, want) {
+		t.Errorf("Attendees = %+v, want %+v after round trip", got, want)
+	}
+
+	if role, _ := findProperty("ATTENDEE", parsed.Events[0].Properties).ParamValue("ROLE"); role != "REQ-PARTICIPANT" {
+		t.Errorf("ROLE = %q, want it to be kept", role)
+	}
+
+	if got := buf.String(); strings.Contains(got, "CUTYPE=INDIVIDUAL") {
+		t.Errorf("Format() = %q, want the default CUTYPE omitted", got)
+	}
+}
+
+func TestFormat_commonNameWithComma(t *testing.T) {
+	start := time.Date(2020, time.February, 11, 10, 0, 0, 0, time.UTC)
+
+	v := NewTimedEvent("uid@example.com", "Meeting", start, start.Add(time.Hour))
+	v.Organizer = &Organizer{Address: "mailto:jdoe@example.com", CommonName: "Doe, John", Dir: "ldap://example.com:6666/o=ABC", SentBy: "mailto:assistant@example.com"}
+	v.Attendees = append(v.Attendees, &Attendee{Address: "mailto:jsmith@example.com", CommonName: "Smith; Jane: PhD"})
+
+	c := NewCalendar()
+	c.SetProduct("-//xyz Corp//NONSGML PDA Calendar Version 1.0//EN", "2.0")
+	c.AddEvent(v)
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+
+	for _, want := range []string{`ORGANIZER;CN="Doe, John";DIR="ldap://example.com:6666/o=ABC";SENT-BY="mailto:assistant@example.com":mailto:jdoe@example.com`, `ATTENDEE;CN="Smith; Jane: PhD":mailto:jsmith@example.com`} {
+		if !strings.Contains(strings.ReplaceAll(got, "\r\n ", ""), want) {
+			t.Errorf("Format() = %q, want it to contain %q", got, want)
+		}
+	}
+
+	parsed, err := Parse(strings.NewReader(got), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pv := parsed.Events[0]
+
+	if !reflect.DeepEqual(pv.Organizer, v.Organizer) {
+		t.Errorf("Organizer = %+v, want %+v after round trip", pv.Organizer, v.Organizer)
+	}
+
+	if len(pv.Attendees) != 1 || pv.Attendees[0].CommonName != "Smith; Jane: PhD" {
+		t.Errorf("Attendees = %+v, want the CN kept after round trip", pv.Attendees)
+	}
+}
+
+func TestFormat_freeBusy(t *testing.T) {
+	file, _ := os.Open("fixtures/freebusy.ics")
+	c, err := Parse(file, nil)
+	file.Close()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+
+	for _, want := range []string{
+		"FREEBUSY:19971015T050000Z/19971015T133000Z,19971015T160000Z/19971015T213000\r\n Z\r\n",
+		"FREEBUSY;FBTYPE=FREE:19971015T223000Z/19971016T050000Z\r\n",
+		"URL:http://www.example.com/calendar/busytime/jsmith.ifb\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Format() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestFormat_calendarName(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"X-WR-CALNAME:Team\\, Paris\r\nX-WR-CALDESC:Meetings of the team\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:uid@example.com\r\nDTSTART:20200211T100000Z\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Name != "Team, Paris" || c.Description != "Meetings of the team" {
+		t.Fatalf("Name = %q, Description = %q, want the X-WR values", c.Name, c.Description)
+	}
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+
+	for _, want := range []string{"NAME:Team\\, Paris\r\n", "X-WR-CALNAME:Team\\, Paris\r\n", "DESCRIPTION:Meetings of the team\r\n", "X-WR-CALDESC:Meetings of the team\r\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Format() = %q, want it to contain %q", got, want)
+		}
+	}
+
+	parsed, err := Parse(strings.NewReader(strings.Replace(ics, "X-WR-CALDESC", "NAME:Team\r\nX-WR-CALDESC", 1)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.Name != "Team" {
+		t.Errorf("Name = %q, want NAME to win over X-WR-CALNAME", parsed.Name)
+	}
+}
+
+func TestFormat_timedEvent(t *testing.T) {
+	start := time.Date(2020, time.February, 11, 10, 0, 0, 0, time.UTC)
+
+	c := NewCalendar()
+	c.SetProduct("-//xyz Corp//NONSGML PDA Calendar Version 1.0//EN", "2.0")
+	c.AddEvent(NewTimedEvent("uid@example.com", "Meeting", start, start.Add(time.Hour)))
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := Parse(&buf, nil, WithStrict(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := parsed.Events[0]
+
+	if v.UID != "uid@example.com" || v.Summary != "Meeting" || !v.StartDate.Equal(start) || !v.EndDate.Equal(start.Add(time.Hour)) {
+		t.Errorf("Event = %+v, want the fields given to NewTimedEvent", v)
+	}
+}
+
+func TestFormat_clock(t *testing.T) {
+	now := time.Date(2020, time.February, 11, 9, 0, 0, 0, time.UTC)
+
+	c := NewCalendar()
+	c.SetProduct("-//xyz Corp//NONSGML PDA Calendar Version 1.0//EN", "2.0")
+
+	v := NewEvent()
+	v.StartDate = time.Date(2020, time.February, 11, 10, 0, 0, 0, time.UTC)
+	c.AddEvent(v)
+
+	format := func() string {
+		var buf bytes.Buffer
+		err := Format(&buf, c,
+			WithClock(func() time.Time { return now }),
+			WithUIDGenerator(func() string { return "generated@example.com" }),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	got := format()
+
+	if want := "UID:generated@example.com\r\nDTSTAMP:20200211T090000Z\r\n"; !strings.Contains(got, want) {
+		t.Errorf("Format() = %q, want it to contain %q", got, want)
+	}
+
+	if again := format(); again != got {
+		t.Errorf("Format() = %q, want the same output %q", again, got)
+	}
+}
+
+func TestFormat_minimal(t *testing.T) {
+	c := NewCalendar()
+	c.SetProduct("-//xyz Corp//NONSGML PDA Calendar Version 1.0//EN", "2.0")
+	c.Calscale = "GREGORIAN"
+	c.Name = "Team"
+	c.Description = "Meetings of the team"
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c, WithMinimal(true)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "BEGIN:VCALENDAR\r\nPRODID:-//xyz Corp//NONSGML PDA Calendar Version 1.0//EN\r\nVERSION:2.0\r\n" +
+		"NAME:Team\r\nDESCRIPTION:Meetings of the team\r\nEND:VCALENDAR\r\n"
+
+	if got := buf.String(); got != want {
+		t.Errorf("Format() = %q, want %q", got, want)
+	}
+
+	c.Properties = []*Property{{Name: "X-WR-CALNAME", Value: "Team"}}
+	buf.Reset()
+
+	if err := Format(&buf, c, WithMinimal(true)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := buf.String(); !strings.Contains(got, "X-WR-CALNAME:Team\r\n") || strings.Contains(got, "X-WR-CALDESC") {
+		t.Errorf("Format() = %q, want only the parsed X-WR-CALNAME", got)
+	}
+}
+
+func TestFormat_lineEnding(t *testing.T) {
+	start := time.Date(2020, time.February, 11, 10, 0, 0, 0, time.UTC)
+
+	c := NewCalendar()
+	c.SetProduct("-//xyz Corp//NONSGML PDA Calendar Version 1.0//EN", "2.0")
+	c.AddEvent(NewTimedEvent("uid@example.com", strings.Repeat("Meeting ", 20), start, start.Add(time.Hour)))
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c, WithLineEnding("\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+
+	if strings.Contains(got, "\r") {
+		t.Errorf("Format() = %q, want no CR", got)
+	}
+
+	if !strings.HasPrefix(got, "BEGIN:VCALENDAR\n") || !strings.Contains(got, "\n ") {
+		t.Errorf("Format() = %q, want LF line endings and folded lines", got)
+	}
+
+	parsed, err := Parse(strings.NewReader(got), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := strings.Repeat("Meeting ", 20); parsed.Events[0].Summary != want {
+		t.Errorf("Summary = %q, want %q", parsed.Events[0].Summary, want)
+	}
+}
+
+func TestFormat_folding(t *testing.T) {
+	start := time.Date(2020, time.February, 11, 10, 0, 0, 0, time.UTC)
+	summary := strings.Repeat("Meeting ", 20)
+
+	c := NewCalendar()
+	c.SetProduct("-//xyz Corp//NONSGML PDA Calendar Version 1.0//EN", "2.0")
+	c.AddEvent(NewTimedEvent("uid@example.com", summary, start, start.Add(time.Hour)))
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c, WithFolding(false)); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+
+	if want := "\r\nSUMMARY:" + summary + "\r\n"; !strings.Contains(got, want) {
+		t.Errorf("Format() = %q, want it to contain %q", got, want)
+	}
+
+	if strings.Contains(got, "\r\n ") {
+		t.Errorf("Format() = %q, want no folded line", got)
+	}
+}
+
+func TestFormat_allDayEndDate(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:parsed@example.com\r\nDTSTART;VALUE=DATE:20200211\r\n" +
+		"DTEND;VALUE=DATE:20200214\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	date := func(day int) time.Time {
+		return time.Date(2020, time.February, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		start     time.Time
+		end       time.Time
+		inclusive bool
+		want      string
+	}{
+		{name: "Exclusive", start: date(11), end: date(14), want: "DTEND;VALUE=DATE:20200214\r\n"},
+		{name: "Inclusive", start: date(11), end: date(13), inclusive: true, want: "DTEND;VALUE=DATE:20200214\r\n"},
+		{name: "Single day", start: date(11), end: date(11), inclusive: true, want: "DTEND;VALUE=DATE:20200212\r\n"},
+		{name: "End on start", start: date(11), end: date(11), want: "DTEND;VALUE=DATE:20200212\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse(strings.NewReader(ics), time.UTC)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			v := NewEvent()
+			v.UID = "uid@example.com"
+			v.Timestamp = date(11)
+			v.StartDate = tt.start
+			v.EndDate = tt.end
+			v.AllDay = true
+			c.AddEvent(v)
+
+			var buf bytes.Buffer
+			if err := Format(&buf, c, WithInclusiveEndDate(tt.inclusive)); err != nil {
+				t.Fatal(err)
+			}
+
+			events := strings.Split(buf.String(), "BEGIN:VEVENT")
+
+			if got := events[1]; !strings.Contains(got, "DTEND;VALUE=DATE:20200214\r\n") {
+				t.Errorf("Format() = %q, want the parsed DTEND kept", got)
+			}
+
+			if got := events[2]; !strings.Contains(got, tt.want) {
+				t.Errorf("Format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat_originalTZID(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:uid@example.com\r\n" +
+		"DTSTART;TZID=/Europe/Paris:20200211T100000\r\nDTEND;TZID=GMT:20200211T100000\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := c.Events[0]
+
+	if got := v.StartDate.Location().String(); got != "Europe/Paris" {
+		t.Errorf("StartDate location = %s, want Europe/Paris", got)
+	}
+
+	v.StartDate = v.StartDate.Add(-time.Hour)
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"DTSTART;TZID=/Europe/Paris:20200211T090000\r\n", "DTEND;TZID=GMT:20200211T100000\r\n"} {
+		if got := buf.String(); !strings.Contains(got, want) {
+			t.Errorf("Format() = %q, want it to contain %q", got, want)
+		}
+	}
+
+	// a date moved to another location gets the name of its location
+	v.StartDate = v.StartDate.UTC()
+	buf.Reset()
+
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "DTSTART:20200211T080000Z\r\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("Format() = %q, want it to contain %q", buf.String(), want)
+	}
+}
+
+func TestFormat_categoriesAndColor(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\nCATEGORIES:Work,Team\\, Paris\r\nCATEGORIES:Meetings\r\nCOLOR:turquoise\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:uid@example.com\r\nDTSTART:20200211T100000Z\r\n" +
+		"CATEGORIES:Review\r\nCOLOR:red\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"Work", "Team, Paris", "Meetings"}
+
+	if !reflect.DeepEqual(c.Categories, want) || c.Color != "turquoise" {
+		t.Fatalf("Categories = %q, Color = %q, want %q and turquoise", c.Categories, c.Color, want)
+	}
+
+	if v := c.Events[0]; v.Color != "red" {
+		t.Fatalf("Event Color = %q, want red", v.Color)
+	}
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := Parse(&buf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(parsed.Categories, want) || parsed.Color != "turquoise" || parsed.Events[0].Color != "red" {
+		t.Errorf("Categories = %q, Color = %q, Event Color = %q after round trip", parsed.Categories, parsed.Color, parsed.Events[0].Color)
+	}
+}
@@ -59,6 +59,18 @@ const (
 	itemEndVEvent
 	itemBeginVAlarm
 	itemEndVAlarm
+	itemBeginVTodo
+	itemEndVTodo
+	itemBeginVJournal
+	itemEndVJournal
+	itemBeginVTimezone
+	itemEndVTimezone
+	itemBeginStandard
+	itemEndStandard
+	itemBeginDaylight
+	itemEndDaylight
+	itemBeginVFreeBusy
+	itemEndVFreeBusy
 )
 
 const eof = -1
@@ -81,7 +93,7 @@ type lexer struct {
 func lex(input string) *lexer {
 	l := &lexer{
 		input: input,
-		items: make(chan item),
+		items: make(chan item, 128),
 	}
 	go l.run() // Concurrently run state machine.
 	return l
@@ -145,6 +157,13 @@ func (l *lexer) nextItem() item {
 	return item
 }
 
+// drain consumes the remaining items so the lexing goroutine can exit.
+// Called by the parser when it stops before the end of the input.
+func (l *lexer) drain() {
+	for range l.items {
+	}
+}
+
 // State functions
 
 const (
@@ -155,6 +174,18 @@ const (
 	endVEvent      = "END:VEVENT"
 	beginValarm    = "BEGIN:VALARM"
 	endVAlarm      = "END:VALARM"
+	beginVTodo     = "BEGIN:VTODO"
+	endVTodo       = "END:VTODO"
+	beginVJournal  = "BEGIN:VJOURNAL"
+	endVJournal    = "END:VJOURNAL"
+	beginVTimezone = "BEGIN:VTIMEZONE"
+	endVTimezone   = "END:VTIMEZONE"
+	beginStandard  = "BEGIN:STANDARD"
+	endStandard    = "END:STANDARD"
+	beginDaylight  = "BEGIN:DAYLIGHT"
+	endDaylight    = "END:DAYLIGHT"
+	beginVFreeBusy = "BEGIN:VFREEBUSY"
+	endVFreeBusy   = "END:VFREEBUSY"
 )
 
 func lexContentLine(l *lexer) stateFn {
@@ -168,6 +199,8 @@ func lexContentLine(l *lexer) stateFn {
 	case r == ',':
 		l.emit(itemComma)
 		return lexParamValue
+	case r == eof:
+		return l.errorf("unexpected end of input in content line")
 	default:
 		return l.errorf("unrecognized character in action: %#U", r)
 	}
@@ -175,12 +208,15 @@ func lexContentLine(l *lexer) stateFn {
 
 // lexNewLine scans CRLF
 func lexNewLine(l *lexer) stateFn {
+	// the last line may lack its CRLF, EOF terminates it
 	if l.peek() == eof {
+		l.emit(itemLineEnd)
+		l.emit(itemEOF)
 		return nil
 	}
 
 	if !strings.HasPrefix(l.input[l.pos:], crlf) {
-		l.errorf("unable to find end of line \"CRLF\"")
+		return l.errorf("unable to find end of line \"CRLF\"")
 	}
 
 	l.pos += len(crlf)
@@ -238,6 +274,78 @@ func lexName(l *lexer) stateFn {
 		return lexNewLine
 	}
 
+	if strings.HasPrefix(l.input[l.pos:], beginVTodo) {
+		l.pos += len(beginVTodo)
+		l.emit(itemBeginVTodo)
+		return lexNewLine
+	}
+
+	if strings.HasPrefix(l.input[l.pos:], endVTodo) {
+		l.pos += len(endVTodo)
+		l.emit(itemEndVTodo)
+		return lexNewLine
+	}
+
+	if strings.HasPrefix(l.input[l.pos:], beginVJournal) {
+		l.pos += len(beginVJournal)
+		l.emit(itemBeginVJournal)
+		return lexNewLine
+	}
+
+	if strings.HasPrefix(l.input[l.pos:], endVJournal) {
+		l.pos += len(endVJournal)
+		l.emit(itemEndVJournal)
+		return lexNewLine
+	}
+
+	if strings.HasPrefix(l.input[l.pos:], beginVTimezone) {
+		l.pos += len(beginVTimezone)
+		l.emit(itemBeginVTimezone)
+		return lexNewLine
+	}
+
+	if strings.HasPrefix(l.input[l.pos:], endVTimezone) {
+		l.pos += len(endVTimezone)
+		l.emit(itemEndVTimezone)
+		return lexNewLine
+	}
+
+	if strings.HasPrefix(l.input[l.pos:], beginStandard) {
+		l.pos += len(beginStandard)
+		l.emit(itemBeginStandard)
+		return lexNewLine
+	}
+
+	if strings.HasPrefix(l.input[l.pos:], endStandard) {
+		l.pos += len(endStandard)
+		l.emit(itemEndStandard)
+		return lexNewLine
+	}
+
+	if strings.HasPrefix(l.input[l.pos:], beginDaylight) {
+		l.pos += len(beginDaylight)
+		l.emit(itemBeginDaylight)
+		return lexNewLine
+	}
+
+	if strings.HasPrefix(l.input[l.pos:], endDaylight) {
+		l.pos += len(endDaylight)
+		l.emit(itemEndDaylight)
+		return lexNewLine
+	}
+
+	if strings.HasPrefix(l.input[l.pos:], beginVFreeBusy) {
+		l.pos += len(beginVFreeBusy)
+		l.emit(itemBeginVFreeBusy)
+		return lexNewLine
+	}
+
+	if strings.HasPrefix(l.input[l.pos:], endVFreeBusy) {
+		l.pos += len(endVFreeBusy)
+		l.emit(itemEndVFreeBusy)
+		return lexNewLine
+	}
+
 Loop:
 	for {
 		switch r := l.next(); {
@@ -277,6 +385,10 @@ Loop:
 		l.emit(itemEqual)
 		return lexParamValue
 	}
+
+	if r == eof {
+		return l.errorf("unexpected end of input in content line")
+	}
 	return l.errorf("missing \"=\" sign after param name, got %#U", r)
 }
 
@@ -307,10 +419,10 @@ func lexParamValue(l *lexer) stateFn {
 		r := l.next()
 
 		if r != '"' {
-			l.errorf("Missing \" for closing value")
-		} else {
-			l.ignore()
+			return l.errorf("Missing \" for closing value")
 		}
+
+		l.ignore()
 	} else {
 		l.backup()
 	Loop:
@@ -356,11 +468,11 @@ func isName(r rune) bool {
 }
 
 func isQSafeChar(r rune) bool {
-	return !unicode.IsControl(r) && r != '"'
+	return r != eof && !unicode.IsControl(r) && r != '"'
 }
 
 func isSafeChar(r rune) bool {
-	return !unicode.IsControl(r) && r != '"' && r != ';' && r != ':' && r != ','
+	return r != eof && !unicode.IsControl(r) && r != '"' && r != ';' && r != ':' && r != ','
 }
 
 func isValueChar(r rune) bool {
@@ -2,7 +2,9 @@ package ical
 
 import (
 	"os"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestLex(t *testing.T) {
@@ -21,3 +23,74 @@ func TestLex(t *testing.T) {
 		}
 	}
 }
+
+func TestLex_missingFinalCRLF(t *testing.T) {
+	lexer := lex("BEGIN:VCALENDAR\r\nVERSION:2.0\r\nEND:VCALENDAR")
+	types := make([]itemType, 0)
+
+	for {
+		item := lexer.nextItem()
+		types = append(types, item.typ)
+
+		if item.typ == itemEOF || item.typ == itemError {
+			break
+		}
+	}
+
+	want := []itemType{
+		itemBeginVCalendar, itemLineEnd,
+		itemName, itemColon, itemValue, itemLineEnd,
+		itemEndVCalendar, itemLineEnd,
+		itemEOF,
+	}
+
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("lex() = %v, want %v", types, want)
+	}
+}
+
+func TestLex_truncatedParam(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "Param name", input: "BEGIN:VCALENDAR\r\nSUMMARY;FO"},
+		{name: "Unquoted value", input: "BEGIN:VCALENDAR\r\nSUMMARY;FOO="},
+		{name: "Quoted value", input: "BEGIN:VCALENDAR\r\nSUMMARY;FOO=\"abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done := make(chan item)
+
+			go func() {
+				var last item
+				for item := range lex(tt.input).items {
+					last = item
+				}
+				done <- last
+			}()
+
+			select {
+			case last := <-done:
+				if last.typ != itemError {
+					t.Errorf("last item is %s, want the scan to stop on an error", last)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("lex() doesn't stop at the end of the input")
+			}
+		})
+	}
+}
+
+func TestLex_missingCRLF(t *testing.T) {
+	lexer := lex("BEGIN:VCALENDAR\nVERSION:2.0\r\n")
+	var last item
+
+	for item := range lexer.items {
+		last = item
+	}
+
+	if last.typ != itemError {
+		t.Errorf("last item is %s, want the scan to stop on the missing CRLF error", last)
+	}
+}
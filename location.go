@@ -0,0 +1,174 @@
+package ical
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A StructuredLocation represent the place of an event as described by
+// Apple's X-APPLE-STRUCTURED-LOCATION property
+type StructuredLocation struct {
+	Title     string
+	Address   string
+	Radius    float64 // in meters
+	Latitude  float64
+	Longitude float64
+}
+
+// A Geo represent the global position of an event, given by the GEO
+// property, in decimal degrees
+type Geo struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Validate checks the latitude is within -90 and 90 and the longitude
+// within -180 and 180
+func (g *Geo) Validate() error {
+	if g.Latitude < -90 || g.Latitude > 90 {
+		return fmt.Errorf("invalid latitude %v, expected a value within -90 and 90", g.Latitude)
+	}
+
+	if g.Longitude < -180 || g.Longitude > 180 {
+		return fmt.Errorf("invalid longitude %v, expected a value within -180 and 180", g.Longitude)
+	}
+
+	return nil
+}
+
+// parseGeo parses the value of a GEO property
+//
+// geovalue = float ";" float ;Latitude and Longitude components
+func parseGeo(value string) (*Geo, error) {
+	lat, long, found := strings.Cut(value, ";")
+
+	if !found {
+		return nil, fmt.Errorf("invalid \"geo\" %s, expected latitude;longitude", value)
+	}
+
+	g := &Geo{}
+	var err error
+
+	if g.Latitude, err = strconv.ParseFloat(lat, 64); err != nil {
+		return nil, fmt.Errorf("invalid \"geo\" latitude %s", lat)
+	}
+
+	if g.Longitude, err = strconv.ParseFloat(long, 64); err != nil {
+		return nil, fmt.Errorf("invalid \"geo\" longitude %s", long)
+	}
+
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// MapURL returns a Google Maps link to the GEO position of the event, it
+// returns an empty string when the event has none
+func (v *Event) MapURL() string {
+	if v.Geo == nil {
+		return ""
+	}
+
+	return "https://maps.google.com/?q=" + v.Geo.coordinates()
+}
+
+// GeoURI returns the RFC 5870 geo URI of the GEO position of the event, it
+// returns an empty string when the event has none
+func (v *Event) GeoURI() string {
+	if v.Geo == nil {
+		return ""
+	}
+
+	return "geo:" + v.Geo.coordinates()
+}
+
+// coordinates formats the position as latitude,longitude
+func (g *Geo) coordinates() string {
+	return strconv.FormatFloat(g.Latitude, 'f', -1, 64) + "," + strconv.FormatFloat(g.Longitude, 'f', -1, 64)
+}
+
+// formatGeo formats the value of a GEO property, with six decimal places
+func formatGeo(g *Geo) string {
+	return strconv.FormatFloat(g.Latitude, 'f', 6, 64) + ";" + strconv.FormatFloat(g.Longitude, 'f', 6, 64)
+}
+
+// StructuredLocation decodes the STRUCTURED-LOCATION or
+// X-APPLE-STRUCTURED-LOCATION property of the event, it returns nil when
+// the event has none
+func (v *Event) StructuredLocation() (*StructuredLocation, error) {
+	for _, prop := range v.Properties {
+		if prop.Name == "X-APPLE-STRUCTURED-LOCATION" || prop.Name == "STRUCTURED-LOCATION" {
+			return DecodeStructuredLocation(prop)
+		}
+	}
+
+	return nil, nil
+}
+
+// DecodeStructuredLocation decodes a structured location property, whose
+// value is a geo URI and whose X-TITLE, X-ADDRESS and X-APPLE-RADIUS params
+// give the details of the place
+//
+// X-APPLE-STRUCTURED-LOCATION;VALUE=URI;X-APPLE-RADIUS=72;X-TITLE=Apple:geo:37.331741,-122.030333
+func DecodeStructuredLocation(prop *Property) (*StructuredLocation, error) {
+	location := &StructuredLocation{}
+	location.Title, _ = prop.ParamValue("X-TITLE")
+	location.Address, _ = prop.ParamValue("X-ADDRESS")
+
+	if radius, ok := prop.ParamValue("X-APPLE-RADIUS"); ok {
+		r, err := strconv.ParseFloat(radius, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid radius %q in %s", radius, prop.Name)
+		}
+
+		location.Radius = r
+	}
+
+	lat, long, err := parseGeoURI(prop.Value)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid location %q in %s: %v", prop.Value, prop.Name, err)
+	}
+
+	location.Latitude = lat
+	location.Longitude = long
+
+	return location, nil
+}
+
+// parseGeoURI extracts the coordinates of a RFC 5870 geo URI
+//
+// geo-URI = "geo:" coordinates *( ";" param )
+// coordinates = num "," num [ "," num ]
+func parseGeoURI(uri string) (float64, float64, error) {
+	if !strings.HasPrefix(strings.ToLower(uri), "geo:") {
+		return 0, 0, fmt.Errorf("expected a geo URI")
+	}
+
+	// some producers escape the commas as in a TEXT value
+	coordinates := strings.ReplaceAll(uri[len("geo:"):], `\`, "")
+	coordinates, _, _ = strings.Cut(coordinates, ";")
+	parts := strings.Split(coordinates, ",")
+
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, 0, fmt.Errorf("expected latitude and longitude")
+	}
+
+	lat, err := strconv.ParseFloat(parts[0], 64)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	long, err := strconv.ParseFloat(parts[1], 64)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return lat, long, nil
+}
@@ -0,0 +1,103 @@
+package ical
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEvent_StructuredLocation(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:-//Apple Inc.//Mac OS X 10.15//EN\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:uid@example.com\r\nDTSTART:20200211T100000Z\r\n" +
+		"LOCATION:Apple Park\\n1 Apple Park Way\\, Cupertino\r\n" +
+		"X-APPLE-STRUCTURED-LOCATION;VALUE=URI;X-ADDRESS=\"1 Apple Park Way, Cupertino\";X-APPLE-RADIUS=141.17;\r\n" +
+		" X-TITLE=Apple Park:geo:37.334900,-122.009020\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.Events[0].StructuredLocation()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &StructuredLocation{
+		Title:     "Apple Park",
+		Address:   "1 Apple Park Way, Cupertino",
+		Radius:    141.17,
+		Latitude:  37.3349,
+		Longitude: -122.00902,
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("StructuredLocation() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeStructuredLocation_invalid(t *testing.T) {
+	for _, value := range []string{"http://example.com", "geo:37.3349", "geo:north,south"} {
+		prop := &Property{Name: "X-APPLE-STRUCTURED-LOCATION", Value: value}
+		if _, err := DecodeStructuredLocation(prop); err == nil {
+			t.Errorf("expected an error for %q", value)
+		}
+	}
+}
+
+func TestEvent_MapURL(t *testing.T) {
+	v := NewEvent()
+
+	if got := v.MapURL(); got != "" {
+		t.Errorf("MapURL() = %q, want empty without GEO", got)
+	}
+
+	v.Geo = &Geo{Latitude: 37.3349, Longitude: -122.00902}
+
+	if got, want := v.MapURL(), "https://maps.google.com/?q=37.3349,-122.00902"; got != want {
+		t.Errorf("MapURL() = %q, want %q", got, want)
+	}
+
+}
+
+func TestEvent_GeoURI(t *testing.T) {
+	v := NewEvent()
+
+	if got := v.GeoURI(); got != "" {
+		t.Errorf("GeoURI() = %q, want empty without GEO", got)
+	}
+
+	v.Geo = &Geo{Latitude: 37.3349, Longitude: -122.00902}
+
+	if got, want := v.GeoURI(), "geo:37.3349,-122.00902"; got != want {
+		t.Errorf("GeoURI() = %q, want %q", got, want)
+	}
+}
+
+func Test_parseGeo(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    *Geo
+		wantErr bool
+	}{
+		{value: "37.386013;-122.082932", want: &Geo{Latitude: 37.386013, Longitude: -122.082932}},
+		{value: "-90;180", want: &Geo{Latitude: -90, Longitude: 180}},
+		{value: "37.386013,-122.082932", wantErr: true},
+		{value: "north;-122.082932", wantErr: true},
+		{value: "91;0", wantErr: true},
+		{value: "0;-180.5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseGeo(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseGeo() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseGeo() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,380 @@
+package ical
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+)
+
+// ToMap converts the modeled fields of the event into a map of plain
+// values, for template engines and JSON APIs. The keys are the snake case
+// names of the fields, times are RFC 3339 strings, durations iCalendar
+// durations and binary data base64 strings. Zero values are left out, as
+// are the alarms, the EXRULEs and the raw properties.
+func (v *Event) ToMap() map[string]interface{} {
+	m := make(map[string]interface{})
+
+	setString(m, "uid", v.UID)
+	setTime(m, "timestamp", v.Timestamp)
+	setTime(m, "start", v.StartDate)
+	setTime(m, "end", v.EndDate)
+
+	if v.Duration != 0 {
+		m["duration"] = formatDuration(v.Duration)
+	}
+
+	if v.AllDay {
+		m["all_day"] = true
+	}
+
+	setString(m, "summary", v.Summary)
+	setString(m, "description", v.Description)
+	setStrings(m, "contacts", v.Contacts)
+	setStrings(m, "categories", v.Categories)
+	setString(m, "color", v.Color)
+
+	if v.Geo != nil {
+		m["geo"] = map[string]interface{}{"latitude": v.Geo.Latitude, "longitude": v.Geo.Longitude}
+	}
+
+	setString(m, "status", string(v.Status))
+
+	if v.Sequence != 0 {
+		m["sequence"] = v.Sequence
+	}
+
+	setTime(m, "modified", v.Modified)
+	setString(m, "transparency", string(v.Transparency))
+	setString(m, "busy_status", string(v.BusyStatus))
+
+	if len(v.Attachments) > 0 {
+		attachments := make([]interface{}, 0, len(v.Attachments))
+
+		for _, attachment := range v.Attachments {
+			attachments = append(attachments, attachmentMap(attachment))
+		}
+
+		m["attachments"] = attachments
+	}
+
+	if len(v.Conferences) > 0 {
+		conferences := make([]interface{}, 0, len(v.Conferences))
+
+		for _, conference := range v.Conferences {
+			c := make(map[string]interface{})
+			setString(c, "uri", conference.URI)
+			setStrings(c, "features", conference.Features)
+			setString(c, "label", conference.Label)
+			conferences = append(conferences, c)
+		}
+
+		m["conferences"] = conferences
+	}
+
+	if v.Organizer != nil {
+		o := make(map[string]interface{})
+		setString(o, "address", v.Organizer.Address)
+		setString(o, "common_name", v.Organizer.CommonName)
+		setString(o, "dir", v.Organizer.Dir)
+		setString(o, "sent_by", v.Organizer.SentBy)
+		m["organizer"] = o
+	}
+
+	if len(v.Attendees) > 0 {
+		attendees := make([]interface{}, 0, len(v.Attendees))
+
+		for _, attendee := range v.Attendees {
+			a := make(map[string]interface{})
+			setString(a, "address", attendee.Address)
+			setString(a, "common_name", attendee.CommonName)
+			setString(a, "dir", attendee.Dir)
+			setString(a, "cutype", string(attendee.CUType))
+			setStrings(a, "delegated_from", attendee.DelegatedFrom)
+			setStrings(a, "delegated_to", attendee.DelegatedTo)
+			setStrings(a, "member", attendee.Member)
+			attendees = append(attendees, a)
+		}
+
+		m["attendees"] = attendees
+	}
+
+	if len(v.Images) > 0 {
+		images := make([]interface{}, 0, len(v.Images))
+
+		for _, image := range v.Images {
+			i := attachmentMap(&image.Attachment)
+			setStrings(i, "display", image.Display)
+			images = append(images, i)
+		}
+
+		m["images"] = images
+	}
+
+	return m
+}
+
+// EventFromMap creates an event from the map representation of ToMap. The
+// lists may be []interface{} as well, and the numbers float64, as decoded
+// by encoding/json. Unknown keys are ignored.
+func EventFromMap(m map[string]interface{}) (*Event, error) {
+	var err error
+	r := &mapReader{m: m, err: &err}
+	v := NewEvent()
+
+	v.UID = r.string("uid")
+	v.Timestamp = r.time("timestamp")
+	v.StartDate = r.time("start")
+	v.EndDate = r.time("end")
+
+	if duration := r.string("duration"); duration != "" && err == nil {
+		v.Duration, err = ParseDuration(duration)
+	}
+
+	v.AllDay = r.bool("all_day")
+	v.Summary = r.string("summary")
+	v.Description = r.string("description")
+	v.Contacts = r.strings("contacts")
+	v.Categories = r.strings("categories")
+	v.Color = r.string("color")
+
+	if geo := r.object("geo"); geo != nil {
+		v.Geo = &Geo{Latitude: geo.float("latitude"), Longitude: geo.float("longitude")}
+	}
+
+	v.Status = Status(r.string("status"))
+	v.Sequence = int(r.float("sequence"))
+	v.Modified = r.time("modified")
+
+	if transparency := r.string("transparency"); transparency != "" {
+		v.Transparency = Transparency(transparency)
+	}
+
+	v.BusyStatus = BusyStatus(r.string("busy_status"))
+
+	for _, a := range r.objects("attachments") {
+		v.Attachments = append(v.Attachments, a.attachment())
+	}
+
+	for _, c := range r.objects("conferences") {
+		v.Conferences = append(v.Conferences, Conference{
+			URI:      c.string("uri"),
+			Features: c.strings("features"),
+			Label:    c.string("label"),
+		})
+	}
+
+	if o := r.object("organizer"); o != nil {
+		v.Organizer = &Organizer{
+			Address:    o.string("address"),
+			CommonName: o.string("common_name"),
+			Dir:        o.string("dir"),
+			SentBy:     o.string("sent_by"),
+		}
+	}
+
+	for _, a := range r.objects("attendees") {
+		v.Attendees = append(v.Attendees, &Attendee{
+			Address:       a.string("address"),
+			CommonName:    a.string("common_name"),
+			Dir:           a.string("dir"),
+			CUType:        CUType(a.string("cutype")),
+			DelegatedFrom: a.strings("delegated_from"),
+			DelegatedTo:   a.strings("delegated_to"),
+			Member:        a.strings("member"),
+		})
+	}
+
+	for _, i := range r.objects("images") {
+		v.Images = append(v.Images, &Image{Attachment: *i.attachment(), Display: i.strings("display")})
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func setString(m map[string]interface{}, key, value string) {
+	if value != "" {
+		m[key] = value
+	}
+}
+
+func setStrings(m map[string]interface{}, key string, values []string) {
+	if len(values) > 0 {
+		m[key] = cloneSlice(values, nil)
+	}
+}
+
+func setTime(m map[string]interface{}, key string, t time.Time) {
+	if !t.IsZero() {
+		m[key] = t.Format(time.RFC3339Nano)
+	}
+}
+
+func attachmentMap(attachment *Attachment) map[string]interface{} {
+	m := make(map[string]interface{})
+	setString(m, "uri", attachment.URI)
+	setString(m, "mime_type", attachment.MimeType)
+
+	if len(attachment.Data) > 0 {
+		m["data"] = base64.StdEncoding.EncodeToString(attachment.Data)
+	}
+
+	return m
+}
+
+// mapReader reads the typed values of a map representation, the first
+// error is kept in err, shared with the readers of the nested maps, and the
+// following reads return zero values
+type mapReader struct {
+	m   map[string]interface{}
+	err *error
+}
+
+// value returns the value of key, nil when it is missing or after an error
+func (r *mapReader) value(key string) interface{} {
+	if *r.err != nil {
+		return nil
+	}
+	return r.m[key]
+}
+
+func (r *mapReader) mismatch(key, expected string, value interface{}) {
+	*r.err = fmt.Errorf("invalid %q, expected %s, found %T", key, expected, value)
+}
+
+func (r *mapReader) string(key string) string {
+	switch value := r.value(key).(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	default:
+		r.mismatch(key, "a string", value)
+		return ""
+	}
+}
+
+func (r *mapReader) bool(key string) bool {
+	switch value := r.value(key).(type) {
+	case nil:
+		return false
+	case bool:
+		return value
+	default:
+		r.mismatch(key, "a boolean", value)
+		return false
+	}
+}
+
+func (r *mapReader) float(key string) float64 {
+	switch value := r.value(key).(type) {
+	case nil:
+		return 0
+	case int:
+		return float64(value)
+	case float64:
+		return value
+	default:
+		r.mismatch(key, "a number", value)
+		return 0
+	}
+}
+
+func (r *mapReader) time(key string) time.Time {
+	value := r.string(key)
+
+	if value == "" {
+		return time.Time{}
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, value)
+
+	if err != nil {
+		*r.err = fmt.Errorf("invalid %q: %w", key, err)
+	}
+
+	return t
+}
+
+func (r *mapReader) strings(key string) []string {
+	values := make([]string, 0)
+
+	switch value := r.value(key).(type) {
+	case nil:
+	case []string:
+		values = append(values, value...)
+	case []interface{}:
+		for _, e := range value {
+			s, ok := e.(string)
+
+			if !ok {
+				r.mismatch(key, "a list of strings", value)
+				return values
+			}
+
+			values = append(values, s)
+		}
+	default:
+		r.mismatch(key, "a list of strings", value)
+	}
+
+	return values
+}
+
+// object returns a reader of the map of key, nil when it is missing
+func (r *mapReader) object(key string) *mapReader {
+	switch value := r.value(key).(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		return &mapReader{m: value, err: r.err}
+	default:
+		r.mismatch(key, "an object", value)
+		return nil
+	}
+}
+
+// objects returns a reader of each map of the list of key
+func (r *mapReader) objects(key string) []*mapReader {
+	readers := make([]*mapReader, 0)
+
+	switch value := r.value(key).(type) {
+	case nil:
+	case []map[string]interface{}:
+		for _, m := range value {
+			readers = append(readers, &mapReader{m: m, err: r.err})
+		}
+	case []interface{}:
+		for _, e := range value {
+			m, ok := e.(map[string]interface{})
+
+			if !ok {
+				r.mismatch(key, "a list of objects", value)
+				return nil
+			}
+
+			readers = append(readers, &mapReader{m: m, err: r.err})
+		}
+	default:
+		r.mismatch(key, "a list of objects", value)
+	}
+
+	return readers
+}
+
+func (r *mapReader) attachment() *Attachment {
+	attachment := &Attachment{URI: r.string("uri"), MimeType: r.string("mime_type")}
+
+	if data := r.string("data"); data != "" {
+		var err error
+
+		if attachment.Data, err = base64.StdEncoding.DecodeString(data); err != nil {
+			*r.err = fmt.Errorf("invalid \"data\": %w", err)
+		}
+	}
+
+	return attachment
+}
@@ -0,0 +1,96 @@
+package ical
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvent_ToMap(t *testing.T) {
+	v := NewEvent()
+	v.UID = "uid@example.com"
+	v.Timestamp = time.Date(2020, time.February, 11, 9, 0, 0, 0, time.UTC)
+	v.StartDate = time.Date(2020, time.February, 11, 10, 0, 0, 0, time.UTC)
+	v.EndDate = time.Date(2020, time.February, 11, 11, 30, 0, 0, time.UTC)
+	v.Duration = 90 * time.Minute
+	v.Summary = "Meeting"
+	v.Description = "Weekly review"
+	v.Contacts = []string{"Jim Dolittle"}
+	v.Categories = []string{"Work", "Review"}
+	v.Color = "red"
+	v.Geo = &Geo{Latitude: 37.386013, Longitude: -122.082932}
+	v.Status = StatusConfirmed
+	v.Sequence = 2
+	v.Modified = time.Date(2020, time.February, 10, 9, 0, 0, 0, time.UTC)
+	v.Transparency = TransparencyTransparent
+	v.Attachments = []*Attachment{{URI: "http://example.com/agenda.pdf", MimeType: "application/pdf"}, {Data: []byte("notes")}}
+	v.Conferences = []Conference{{URI: "https://chat.example.com/audio", Features: []string{"AUDIO"}, Label: "Audio"}}
+	v.Organizer = &Organizer{Address: "mailto:jsmith@example.com", CommonName: "John Smith"}
+	v.Attendees = []*Attendee{{Address: "mailto:jdoe@example.com", CUType: CUTypeIndividual, Member: []string{"mailto:dev@example.com"}}}
+	v.Images = []*Image{{Attachment: Attachment{URI: "http://example.com/logo.png"}, Display: []string{"BADGE"}}}
+
+	m := v.ToMap()
+
+	if m["start"] != "2020-02-11T10:00:00Z" || m["duration"] != "PT1H30M" || m["sequence"] != 2 {
+		t.Errorf("ToMap() = %v, want typed values", m)
+	}
+
+	if _, ok := m["busy_status"]; ok {
+		t.Errorf("ToMap() = %v, want the zero values left out", m)
+	}
+
+	got, err := EventFromMap(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the empty lists may be nil or not, the maps leave them out
+	if !reflect.DeepEqual(got.ToMap(), m) {
+		t.Errorf("EventFromMap() = %v, want %v", got.ToMap(), m)
+	}
+
+	// the same map once encoded to and decoded from JSON
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err = EventFromMap(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got.ToMap(), m) {
+		t.Errorf("EventFromMap() = %v from JSON, want %v", got.ToMap(), m)
+	}
+}
+
+func TestEventFromMap_errors(t *testing.T) {
+	tests := []struct {
+		name string
+		m    map[string]interface{}
+		want string
+	}{
+		{name: "String", m: map[string]interface{}{"summary": 1}, want: `invalid "summary", expected a string, found int`},
+		{name: "Time", m: map[string]interface{}{"start": "tomorrow"}, want: `invalid "start": parsing time "tomorrow"`},
+		{name: "Duration", m: map[string]interface{}{"duration": "1h"}, want: `invalid duration "1h", expected "P"`},
+		{name: "Nested", m: map[string]interface{}{"geo": map[string]interface{}{"latitude": "north"}}, want: `invalid "latitude", expected a number, found string`},
+		{name: "List", m: map[string]interface{}{"categories": []interface{}{"Work", 1}}, want: `invalid "categories", expected a list of strings, found []interface {}`},
+		{name: "Data", m: map[string]interface{}{"attachments": []interface{}{map[string]interface{}{"data": "!"}}}, want: `invalid "data": illegal base64 data at input byte 0`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := EventFromMap(tt.m)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("EventFromMap() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
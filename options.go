@@ -0,0 +1,189 @@
+package ical
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// An Option configures the parser
+type Option func(*options)
+
+// options holds the parser configuration
+type options struct {
+	strict            bool
+	rawSource         bool
+	outlookBusyStatus bool
+	relaxedDTStamp    bool
+	xwrTimezone       bool
+	autoQuirks        bool
+	windowsTimezones  bool
+	preamble          func(*Calendar) error
+
+	// the quirks explicitly set, which WithAutoQuirks doesn't override
+	outlookBusyStatusSet bool
+	xwrTimezoneSet       bool
+}
+
+// WithStrict enables the strict mode, in which the problems tolerated by
+// default are reported as errors instead of being collected in
+// Calendar.Warnings
+func WithStrict(strict bool) Option {
+	return func(o *options) {
+		o.strict = strict
+	}
+}
+
+// WithRawSource keeps the unfolded source text of each parsed event,
+// available through Event.Raw. It is disabled by default to save the
+// memory cost.
+func WithRawSource(raw bool) Option {
+	return func(o *options) {
+		o.rawSource = raw
+	}
+}
+
+// WithOutlookBusyStatus parses the Outlook X-MICROSOFT-CDO-BUSYSTATUS
+// property into Event.BusyStatus. When TRANSP is missing, a FREE event is
+// then TRANSPARENT and any other status OPAQUE.
+func WithOutlookBusyStatus(enabled bool) Option {
+	return func(o *options) {
+		o.outlookBusyStatus = enabled
+		o.outlookBusyStatusSet = true
+	}
+}
+
+// WithRelaxedDTStamp accepts the events, todos and journals missing their
+// required DTSTAMP, which then defaults to their DTSTART, or the current time
+// without one
+func WithRelaxedDTStamp(relaxed bool) Option {
+	return func(o *options) {
+		o.relaxedDTStamp = relaxed
+	}
+}
+
+// WithXWRTimezone reads the floating times in the timezone given by the
+// X-WR-TIMEZONE calendar property, as Google Calendar does, instead of the
+// location passed to Parse. An unknown timezone is ignored.
+func WithXWRTimezone(enabled bool) Option {
+	return func(o *options) {
+		o.xwrTimezone = enabled
+		o.xwrTimezoneSet = true
+	}
+}
+
+// WithAutoQuirks enables the quirks of the producer of the calendar, as
+// detected from its PRODID: WithXWRTimezone for Google Calendar and
+// WithOutlookBusyStatus for Microsoft products. A quirk given its own option
+// keeps it, whatever the producer. The Windows timezone names Outlook uses
+// as TZID are resolved whatever the option, see WithWindowsTimezones.
+func WithAutoQuirks(enabled bool) Option {
+	return func(o *options) {
+		o.autoQuirks = enabled
+	}
+}
+
+// WithWindowsTimezones resolves the Windows timezone names Outlook and
+// Exchange use as TZID, "W. Europe Standard Time" for instance, to their
+// IANA location. It is enabled by default, when disabled such a TZID is
+// unknown and its times are read as UTC.
+func WithWindowsTimezones(enabled bool) Option {
+	return func(o *options) {
+		o.windowsTimezones = enabled
+	}
+}
+
+// WithPreamble calls fn with the calendar once its properties are parsed,
+// before its first event, todo, journal or freebusy, or at its end when it
+// has none. Returning an error stops the parsing. It is mostly useful with
+// ParseEvents, which doesn't return the calendar.
+func WithPreamble(fn func(c *Calendar) error) Option {
+	return func(o *options) {
+		o.preamble = fn
+	}
+}
+
+// A FormatOption configures the formatter
+type FormatOption func(*formatConfig)
+
+// formatConfig holds the formatter configuration
+type formatConfig struct {
+	clock            func() time.Time
+	uid              func() string
+	minimal          bool
+	lineEnding       string
+	inclusiveEndDate bool
+	folding          bool
+}
+
+func defaultFormatConfig() formatConfig {
+	return formatConfig{
+		clock:      time.Now,
+		uid:        randomUID,
+		lineEnding: crlf,
+		folding:    true,
+	}
+}
+
+// WithClock sets the clock giving the DTSTAMP of the components which have
+// none, it defaults to time.Now
+func WithClock(clock func() time.Time) FormatOption {
+	return func(c *formatConfig) {
+		c.clock = clock
+	}
+}
+
+// WithUIDGenerator sets the generator giving the UID of the components
+// which have none, it defaults to random hexadecimal identifiers
+func WithUIDGenerator(uid func() string) FormatOption {
+	return func(c *formatConfig) {
+		c.uid = uid
+	}
+}
+
+// WithMinimal restricts the output to the required properties and those
+// explicitly set. The X-WR-CALNAME and X-WR-CALDESC properties mirroring the
+// calendar Name and Description for older clients are then only written when
+// the calendar was parsed with them, and the default GREGORIAN calendar scale
+// is never written.
+func WithMinimal(minimal bool) FormatOption {
+	return func(c *formatConfig) {
+		c.minimal = minimal
+	}
+}
+
+// WithLineEnding sets the line ending of the output, including the one of
+// the folded lines. RFC 5545 requires the default CRLF, "\n" is convenient
+// for storage and diffs, the parser accepts both.
+func WithLineEnding(sep string) FormatOption {
+	return func(c *formatConfig) {
+		c.lineEnding = sep
+	}
+}
+
+// WithInclusiveEndDate makes the EndDate of the all-day events the last day
+// they occur on, the formatter then writes the following day as the
+// exclusive DTEND required by RFC 5545. An event spanning February 11 to 13
+// has its EndDate on February 13 and gets a DTEND of 20200214. Parsed events
+// keep their DTEND as long as their EndDate is unchanged.
+func WithInclusiveEndDate(inclusive bool) FormatOption {
+	return func(c *formatConfig) {
+		c.inclusiveEndDate = inclusive
+	}
+}
+
+// WithFolding sets whether the content lines longer than 75 octets are
+// folded, as RFC 5545 requires by default. Unfolded output is easier to read
+// and to compare, and some consumers fold the lines themselves.
+func WithFolding(folding bool) FormatOption {
+	return func(c *formatConfig) {
+		c.folding = folding
+	}
+}
+
+// randomUID generates a random 128 bits identifier
+func randomUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -1,6 +1,7 @@
 package ical
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
@@ -11,31 +12,261 @@ import (
 
 // A Calendar represents the whole iCalendar
 type Calendar struct {
-	Properties []*Property
-	Events     []*Event
-	Prodid     string
-	Version    string
-	Calscale   string
-	Method     string
+	Properties  []*Property
+	Events      []*Event
+	Todos       []*Todo
+	Journals    []*Journal
+	FreeBusys   []*FreeBusy
+	Timezones   []*Timezone
+	Prodid      string
+	Version     string
+	Calscale    string
+	Method      string // iTIP method, empty unless the calendar is a scheduling message
+	Name        string
+	Description string
+	Categories  []string
+	Color       string // CSS3 color name, a display hint of RFC 7986
+	Images      []*Image
+	Components  []*Component // components of a registered handler, see RegisterComponent
+	Warnings    []error
+
+	order []interface{} // parsed components in document order, see Walk
 }
 
+// iTIP methods (RFC 5546) of a scheduling calendar
+//
+// The method relaxes the validation of the components: when set, DTSTAMP
+// may be missing from events, todos and journals, and a REPLY or CANCEL
+// may hold events without DTSTART.
+const (
+	MethodPublish        = "PUBLISH"
+	MethodRequest        = "REQUEST"
+	MethodReply          = "REPLY"
+	MethodAdd            = "ADD"
+	MethodCancel         = "CANCEL"
+	MethodRefresh        = "REFRESH"
+	MethodCounter        = "COUNTER"
+	MethodDeclineCounter = "DECLINECOUNTER"
+)
+
+var methods = []string{MethodPublish, MethodRequest, MethodReply, MethodAdd, MethodCancel, MethodRefresh, MethodCounter, MethodDeclineCounter}
+
 // An Event represent a VEVENT component in an iCalendar
 type Event struct {
+	Properties   []*Property
+	Alarms       []*Alarm
+	UID          string
+	Timestamp    time.Time
+	StartDate    time.Time
+	EndDate      time.Time
+	Duration     time.Duration
+	AllDay       bool
+	ExRules      []*Recurrence // deprecated EXRULE of the legacy feeds, excluded by Expand
+	Summary      string
+	Description  string
+	Contacts     []string
+	Categories   []string
+	Color        string // CSS3 color name, a display hint of RFC 7986
+	Geo          *Geo
+	Status       Status
+	Sequence     int
+	Modified     time.Time
+	Transparency Transparency
+	BusyStatus   BusyStatus // only parsed with the WithOutlookBusyStatus option
+	Attachments  []*Attachment
+	Conferences  []Conference
+	Organizer    *Organizer
+	Attendees    []*Attendee
+	Images       []*Image
+	raw          string
+}
+
+// A Todo represent a VTODO component in an iCalendar
+type Todo struct {
+	Properties      []*Property
+	Alarms          []*Alarm
+	UID             string
+	Timestamp       time.Time
+	StartDate       time.Time
+	Due             time.Time
+	Completed       time.Time
+	Summary         string
+	Description     string
+	PercentComplete int
+	Status          Status
+}
+
+// A Journal represent a VJOURNAL component in an iCalendar
+type Journal struct {
 	Properties  []*Property
-	Alarms      []*Alarm
 	UID         string
 	Timestamp   time.Time
 	StartDate   time.Time
-	EndDate     time.Time
 	Summary     string
 	Description string
+	Status      Status
+}
+
+// A FreeBusy represent a VFREEBUSY component in an iCalendar
+type FreeBusy struct {
+	Properties []*Property
+	UID        string
+	Timestamp  time.Time
+	StartDate  time.Time
+	EndDate    time.Time
+	Periods    []*Period
+}
+
+// A Period represent a period of time of a FREEBUSY property, tagged with
+// the FBTYPE of the property
+type Period struct {
+	Start time.Time
+	End   time.Time
+	Type  FreeBusyType
+}
+
+// A FreeBusyType tells whether a period is free or busy
+type FreeBusyType string
+
+// Free/busy types of a FREEBUSY property, BUSY is the default
+const (
+	FreeBusyFree            FreeBusyType = "FREE"
+	FreeBusyBusy            FreeBusyType = "BUSY"
+	FreeBusyBusyUnavailable FreeBusyType = "BUSY-UNAVAILABLE"
+	FreeBusyBusyTentative   FreeBusyType = "BUSY-TENTATIVE"
+)
+
+// A Timezone represent a VTIMEZONE component in an iCalendar, it defines
+// the TZID referenced by the DATE-TIME properties of the other components
+type Timezone struct {
+	Properties []*Property
+	TZID       string
+	Standard   []*TimezoneRule
+	Daylight   []*TimezoneRule
 }
 
+// A TimezoneRule represent a STANDARD or DAYLIGHT sub-component of a
+// VTIMEZONE
+type TimezoneRule struct {
+	Properties []*Property
+	StartDate  time.Time
+	OffsetFrom int // seconds east of UTC
+	OffsetTo   int // seconds east of UTC
+	Name       string
+}
+
+// A Status represent the overall status of a component
+type Status string
+
+// Statuses of a VEVENT
+const (
+	StatusTentative Status = "TENTATIVE"
+	StatusConfirmed Status = "CONFIRMED"
+	StatusCancelled Status = "CANCELLED"
+)
+
+// Statuses of a VTODO, along with StatusCancelled
+const (
+	StatusNeedsAction Status = "NEEDS-ACTION"
+	StatusCompleted   Status = "COMPLETED"
+	StatusInProcess   Status = "IN-PROCESS"
+)
+
+// Statuses of a VJOURNAL, along with StatusCancelled
+const (
+	StatusDraft Status = "DRAFT"
+	StatusFinal Status = "FINAL"
+)
+
+// A Transparency tells whether an event consumes time on a calendar
+type Transparency string
+
+// Transparencies of a VEVENT
+const (
+	TransparencyOpaque      Transparency = "OPAQUE"
+	TransparencyTransparent Transparency = "TRANSPARENT"
+)
+
+// A BusyStatus is the Outlook busy status of an event, given by the
+// X-MICROSOFT-CDO-BUSYSTATUS property
+type BusyStatus string
+
+// Busy statuses of a VEVENT generated by Outlook or Exchange
+const (
+	BusyStatusFree      BusyStatus = "FREE"
+	BusyStatusTentative BusyStatus = "TENTATIVE"
+	BusyStatusBusy      BusyStatus = "BUSY"
+	BusyStatusOOF       BusyStatus = "OOF"
+)
+
+// A CUType is the type of a calendar user, given by the CUTYPE param
+type CUType string
+
+// Calendar user types, x-name and iana-token values are allowed too
+const (
+	CUTypeIndividual CUType = "INDIVIDUAL"
+	CUTypeGroup      CUType = "GROUP"
+	CUTypeResource   CUType = "RESOURCE"
+	CUTypeRoom       CUType = "ROOM"
+	CUTypeUnknown    CUType = "UNKNOWN"
+)
+
+var (
+	eventStatuses   = []Status{StatusTentative, StatusConfirmed, StatusCancelled}
+	todoStatuses    = []Status{StatusNeedsAction, StatusCompleted, StatusInProcess, StatusCancelled}
+	journalStatuses = []Status{StatusDraft, StatusFinal, StatusCancelled}
+)
+
 // An Alarm represent a VALARM component in an iCalendar
 type Alarm struct {
-	Properties []*Property
-	Action     string
-	Trigger    string
+	Properties  []*Property
+	Action      string
+	Trigger     string
+	TriggerDate time.Time // set when the trigger is an absolute date-time
+	Attachments []*Attachment
+}
+
+// An Attachment represent an ATTACH property, either referenced by an URI
+// or inlined as binary data
+type Attachment struct {
+	URI      string
+	Data     []byte
+	MimeType string
+}
+
+// An Image represent an IMAGE property, a graphic for a calendar or an
+// event (RFC 7986)
+type Image struct {
+	Attachment
+	Display []string // how the image is meant to be displayed: BADGE, GRAPHIC, FULLSIZE or THUMBNAIL
+}
+
+// A Conference represent a CONFERENCE property, giving the way to join an
+// online meeting (RFC 7986)
+type Conference struct {
+	URI      string
+	Features []string
+	Label    string
+}
+
+// An Organizer represent an ORGANIZER property, the calendar user who
+// organizes an event
+type Organizer struct {
+	Address    string // calendar user address, usually a mailto: URI
+	CommonName string
+	Dir        string // directory entry of the user, usually a LDAP URI
+	SentBy     string // address of the user acting on behalf of the organizer
+}
+
+// An Attendee represent an ATTENDEE property, a participant of an event
+type Attendee struct {
+	Address       string // calendar user address, usually a mailto: URI
+	CommonName    string
+	Dir           string // directory entry of the user, usually a LDAP URI
+	CUType        CUType // INDIVIDUAL when the CUTYPE param is missing
+	DelegatedFrom []string
+	DelegatedTo   []string
+	Member        []string // groups the attendee belongs to
 }
 
 // A Property represent an unparsed property in an iCalendar component
@@ -48,6 +279,35 @@ type Property struct {
 // A Param represent a list of param for a property
 type Param struct {
 	Values []string
+
+	// number of values of each occurrence of a repeated param, in document
+	// order, nil when the param isn't repeated
+	lengths []int
+}
+
+// occurrences splits the values of the param by occurrence, so that a
+// repeated param is written back as such. They are a single occurrence
+// when the values were changed since the param was parsed.
+func (param *Param) occurrences() [][]string {
+	total := 0
+
+	for _, n := range param.lengths {
+		total += n
+	}
+
+	if len(param.lengths) < 2 || total != len(param.Values) {
+		return [][]string{param.Values}
+	}
+
+	occurrences := make([][]string, 0, len(param.lengths))
+	values := param.Values
+
+	for _, n := range param.lengths {
+		occurrences = append(occurrences, values[:n])
+		values = values[n:]
+	}
+
+	return occurrences
 }
 
 type parser struct {
@@ -55,20 +315,108 @@ type parser struct {
 	token     [2]item
 	peekCount int
 	scope     int
+	scopes    []int
 	c         *Calendar
 	v         *Event
+	t         *Todo
+	j         *Journal
+	fb        *FreeBusy
 	a         *Alarm
+	z         *Timezone
+	r         *TimezoneRule
 	location  *time.Location
+	rawStart  int
+	onEvent   func(*Event) error // receives the events instead of the calendar
+	preambled bool               // the preamble hook was called
+	pending   []pendingCheck     // checks waiting for the calendar METHOD
+	custom    []*Component       // registered components being read, innermost last
+	tzids     []unresolvedTZID   // TZIDs which may be defined by a later VTIMEZONE
+
+	events          int              // number of events in the input, at most
+	eventProperties int              // estimated number of properties of an event
+	handler         ComponentHandler // handler of the outermost registered component
+	options
+}
+
+// pendingCheck is a requirement of a component which depends on the
+// calendar METHOD, unknown when the component ended
+type pendingCheck struct {
+	err     error
+	relaxed func(method string) bool
+}
+
+// maxEventProperties caps the properties preallocated for each event, a few
+// events may hold most of the content lines
+const maxEventProperties = 32
+
+// unresolvedTZID is a TZID of an event unknown to time.LoadLocation, it
+// must be defined by a VTIMEZONE of the calendar
+type unresolvedTZID struct {
+	tzid string
+	err  error
 }
 
 // Parse transforms the raw iCalendar into a Calendar struct
 // It's up to the caller to close the io.Reader
 // if the time.Location parameter is not set, it will default to the system location
-func Parse(r io.Reader, l *time.Location) (*Calendar, error) {
+// Parse is safe for concurrent use, each call owns its parsing state
+func Parse(r io.Reader, l *time.Location, opts ...Option) (*Calendar, error) {
+	p, err := newParser(r, l, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.c.Events = make([]*Event, 0, p.events)
+
+	return p.parse()
+}
+
+// ParseEvents parses the iCalendar read from r like Parse, but hands each
+// event to fn as soon as it is parsed instead of keeping it in
+// Calendar.Events, capping the memory used by large feeds. Parsing stops at
+// the first error returned by fn, which is returned as is. Times are parsed
+// in l like Parse, and the calendar properties are given to the WithPreamble
+// hook.
+func ParseEvents(r io.Reader, l *time.Location, fn func(*Event) error, opts ...Option) error {
+	p, err := newParser(r, l, opts)
+
+	if err != nil {
+		return err
+	}
+
+	p.onEvent = fn
+	_, err = p.parse()
+	return err
+}
+
+// ParseMany parses each reader independently like Parse, a failing feed
+// doesn't stop the others. The calendars and errors are indexed like the
+// readers, a calendar is nil when its error isn't. Times are parsed in l
+// like Parse.
+func ParseMany(readers []io.Reader, l *time.Location, opts ...Option) ([]*Calendar, []error) {
+	calendars := make([]*Calendar, len(readers))
+	errs := make([]error, len(readers))
+
+	for i, r := range readers {
+		calendars[i], errs[i] = Parse(r, l, opts...)
+	}
+
+	return calendars, errs
+}
+
+// newParser reads the iCalendar from r and starts lexing it
+func newParser(r io.Reader, l *time.Location, opts []Option) (*parser, error) {
 	p := &parser{}
+	p.windowsTimezones = true
+
+	for _, opt := range opts {
+		opt(&p.options)
+	}
+
 	p.c = NewCalendar()
 	p.scope = scopeCalendar
-	bytes, err := ioutil.ReadAll(r)
+	input, lines, err := readInput(r)
 
 	if err != nil {
 		return nil, err
@@ -79,19 +427,83 @@ func Parse(r io.Reader, l *time.Location) (*Calendar, error) {
 	}
 
 	p.location = l
+	p.lex = lex(input)
 
-	text := unfold(string(bytes))
-	p.lex = lex(text)
-	return p.parse()
+	// avoid growing the events slice and their properties on large feeds
+	p.events = strings.Count(input, beginVEvent)
+	p.eventProperties = lines / (p.events + 1)
+
+	if p.eventProperties > maxEventProperties {
+		p.eventProperties = maxEventProperties
+	}
+
+	return p, nil
+}
+
+// CountComponents counts the components of the iCalendar read from r by
+// name, VEVENT, VALARM or X-WR-FOO for instance, without building the
+// calendar. The content lines are scanned but not validated, it is meant for
+// a cheap triage of the feeds before parsing them.
+func CountComponents(r io.Reader) (map[string]int, error) {
+	input, _, err := readInput(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := lex(input)
+	defer l.drain()
+
+	counts := make(map[string]int)
+	begin := false
+
+	for {
+		item := l.nextItem()
+
+		switch item.typ {
+		case itemError:
+			return nil, fmt.Errorf("%s", item.val)
+		case itemEOF:
+			return counts, nil
+		case itemLineEnd:
+			begin = false
+		case itemName:
+			// the components unknown to the lexer are a BEGIN property
+			begin = strings.EqualFold(item.val, "BEGIN")
+		case itemValue:
+			if begin {
+				counts[strings.ToUpper(item.val)]++
+			}
+		default:
+			if name, found := strings.CutPrefix(item.val, "BEGIN:"); found && item.typ > itemKeyword {
+				counts[name]++
+			}
+		}
+	}
 }
 
-// NewCalendar creates an empty Calendar
+// DefaultProdID is the PRODID of the calendars created by NewCalendar, see
+// SetProduct
+const DefaultProdID = "-//luxifer//ical//EN"
+
+// NewCalendar creates an empty Calendar, its PRODID and VERSION set so it
+// is valid once formatted
 func NewCalendar() *Calendar {
 	c := &Calendar{
+		Prodid:   DefaultProdID,
+		Version:  "2.0",
 		Calscale: "GREGORIAN",
 	}
 	c.Properties = make([]*Property, 0)
 	c.Events = make([]*Event, 0)
+	c.Todos = make([]*Todo, 0)
+	c.Journals = make([]*Journal, 0)
+	c.FreeBusys = make([]*FreeBusy, 0)
+	c.Timezones = make([]*Timezone, 0)
+	c.Categories = make([]string, 0)
+	c.Images = make([]*Image, 0)
+	c.Components = make([]*Component, 0)
+	c.Warnings = make([]error, 0)
 	return c
 }
 
@@ -104,16 +516,77 @@ func NewProperty() *Property {
 
 // NewEvent creates an empty Event
 func NewEvent() *Event {
-	v := &Event{}
+	v := &Event{
+		Transparency: TransparencyOpaque,
+	}
 	v.Properties = make([]*Property, 0)
 	v.Alarms = make([]*Alarm, 0)
+	v.Contacts = make([]string, 0)
+	v.Categories = make([]string, 0)
+	v.Attachments = make([]*Attachment, 0)
+	v.Conferences = make([]Conference, 0)
+	v.Attendees = make([]*Attendee, 0)
+	v.Images = make([]*Image, 0)
+	v.ExRules = make([]*Recurrence, 0)
+	return v
+}
+
+// NewTimedEvent creates an Event from start to end, stamped with the
+// current time, which is ready to be formatted
+func NewTimedEvent(uid, summary string, start, end time.Time) *Event {
+	v := NewEvent()
+	v.UID = uid
+	v.Summary = summary
+	v.StartDate = start
+	v.EndDate = end
+	v.Timestamp = time.Now().UTC()
 	return v
 }
 
+// NewTodo creates an empty Todo
+func NewTodo() *Todo {
+	t := &Todo{}
+	t.Properties = make([]*Property, 0)
+	t.Alarms = make([]*Alarm, 0)
+	return t
+}
+
+// NewJournal creates an empty Journal
+func NewJournal() *Journal {
+	j := &Journal{}
+	j.Properties = make([]*Property, 0)
+	return j
+}
+
+// NewFreeBusy creates an empty FreeBusy
+func NewFreeBusy() *FreeBusy {
+	fb := &FreeBusy{}
+	fb.Properties = make([]*Property, 0)
+	fb.Periods = make([]*Period, 0)
+	return fb
+}
+
+// NewTimezone creates an empty Timezone
+func NewTimezone() *Timezone {
+	z := &Timezone{}
+	z.Properties = make([]*Property, 0)
+	z.Standard = make([]*TimezoneRule, 0)
+	z.Daylight = make([]*TimezoneRule, 0)
+	return z
+}
+
+// NewTimezoneRule creates an empty TimezoneRule
+func NewTimezoneRule() *TimezoneRule {
+	r := &TimezoneRule{}
+	r.Properties = make([]*Property, 0)
+	return r
+}
+
 // NewAlarm creates an empty Alarm
 func NewAlarm() *Alarm {
 	a := &Alarm{}
 	a.Properties = make([]*Property, 0)
+	a.Attachments = make([]*Attachment, 0)
 	return a
 }
 
@@ -124,9 +597,49 @@ func NewParam() *Param {
 	return p
 }
 
-// unfold convert multiple line value to one line
-func unfold(text string) string {
-	return strings.Replace(text, "\r\n ", "", -1)
+// readInput reads the iCalendar from r, turning the bare LF line endings
+// into CRLF, as feeds stitched from multiple sources may mix both, and
+// unfolding the content lines. Both are done in the single copy of the
+// input into a string. It returns the number of content lines too.
+func readInput(r io.Reader) (string, int, error) {
+	data, err := ioutil.ReadAll(r)
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	var b strings.Builder
+	b.Grow(len(data))
+	lines := 0
+	start := 0
+
+	for i := 0; i < len(data); i++ {
+		if data[i] != '\n' {
+			continue
+		}
+
+		end := i
+
+		if end > start && data[end-1] == '\r' {
+			end--
+		}
+
+		b.Write(data[start:end])
+		start = i + 1
+
+		// a line starting with a space continues the previous one
+		if start < len(data) && data[start] == ' ' {
+			start++
+			continue
+		}
+
+		b.WriteString(crlf)
+		lines++
+	}
+
+	b.Write(data[start:])
+
+	return b.String(), lines, nil
 }
 
 // next returns the next token.
@@ -144,14 +657,17 @@ func (p *parser) backup() {
 	p.peekCount++
 }
 
-// enterScope switch scope between Calendar, Event and Alarm
-func (p *parser) enterScope() {
-	p.scope++
+// enterScope switch scope between Calendar, Event, Todo, Journal, FreeBusy,
+// Alarm, Timezone and its rules
+func (p *parser) enterScope(scope int) {
+	p.scopes = append(p.scopes, p.scope)
+	p.scope = scope
 }
 
 // leaveScope returns to previous scope
 func (p *parser) leaveScope() {
-	p.scope--
+	p.scope = p.scopes[len(p.scopes)-1]
+	p.scopes = p.scopes[:len(p.scopes)-1]
 }
 
 // parse
@@ -160,17 +676,28 @@ const (
 	scopeCalendar int = iota
 	scopeEvent
 	scopeAlarm
+	scopeTodo
+	scopeJournal
+	scopeTimezone
+	scopeTimezoneRule
+	scopeFreeBusy
 )
 
 const (
 	dateLayout              = "20060102"
 	dateTimeLayoutUTC       = "20060102T150405Z"
 	dateTimeLayoutLocalized = "20060102T150405"
+
+	// off-spec layouts with a numeric UTC offset
+	dateTimeLayoutOffset        = "20060102T150405-0700"
+	dateTimeLayoutOffsetSeconds = "20060102T150405-070000"
 )
 
 var errorDone = errors.New("done")
 
 func (p *parser) parse() (*Calendar, error) {
+	defer p.lex.drain()
+
 	if item := p.next(); item.typ != itemBeginVCalendar {
 		return nil, fmt.Errorf("found %s, expected BEGIN:VCALENDAR", item)
 	}
@@ -187,22 +714,127 @@ func (p *parser) parse() (*Calendar, error) {
 		}
 
 		if err != nil {
-			return nil, err
+			return nil, p.errorContext(err)
 		}
 	}
 
 	return p.c, nil
 }
 
+// scopeNames maps a scope to the name of its component
+var scopeNames = map[int]string{
+	scopeCalendar:     "VCALENDAR",
+	scopeEvent:        "VEVENT",
+	scopeAlarm:        "VALARM",
+	scopeTodo:         "VTODO",
+	scopeJournal:      "VJOURNAL",
+	scopeTimezone:     "VTIMEZONE",
+	scopeTimezoneRule: "STANDARD/DAYLIGHT",
+	scopeFreeBusy:     "VFREEBUSY",
+}
+
+// errorContext prefixes err with the component being parsed and, when
+// known, the UID of the enclosing event, todo, journal or freebusy
+func (p *parser) errorContext(err error) error {
+	if p.scope == scopeCalendar {
+		return err
+	}
+
+	var properties []*Property
+
+	for _, scope := range append(p.scopes, p.scope) {
+		switch scope {
+		case scopeEvent:
+			properties = p.v.Properties
+		case scopeTodo:
+			properties = p.t.Properties
+		case scopeJournal:
+			properties = p.j.Properties
+		case scopeFreeBusy:
+			properties = p.fb.Properties
+		}
+	}
+
+	if uid := findProperty("UID", properties); uid != nil {
+		return fmt.Errorf("error in %s (UID=%s): %w", scopeNames[p.scope], uid.Value, err)
+	}
+
+	return fmt.Errorf("error in %s: %w", scopeNames[p.scope], err)
+}
+
+// beginScopes maps the BEGIN delimiters to the scope they enter, along
+// with the scopes they may appear in
+var beginScopes = map[itemType]struct {
+	scope   int
+	parents []int
+}{
+	itemBeginVEvent:    {scopeEvent, []int{scopeCalendar}},
+	itemBeginVTodo:     {scopeTodo, []int{scopeCalendar}},
+	itemBeginVJournal:  {scopeJournal, []int{scopeCalendar}},
+	itemBeginVFreeBusy: {scopeFreeBusy, []int{scopeCalendar}},
+	itemBeginVTimezone: {scopeTimezone, []int{scopeCalendar}},
+	itemBeginVAlarm:    {scopeAlarm, []int{scopeEvent, scopeTodo}},
+	itemBeginStandard:  {scopeTimezoneRule, []int{scopeTimezone}},
+	itemBeginDaylight:  {scopeTimezoneRule, []int{scopeTimezone}},
+}
+
+// endScopes maps the END delimiters to the scope they leave
+var endScopes = map[itemType]int{
+	itemEndVCalendar: scopeCalendar,
+	itemEndVEvent:    scopeEvent,
+	itemEndVTodo:     scopeTodo,
+	itemEndVJournal:  scopeJournal,
+	itemEndVFreeBusy: scopeFreeBusy,
+	itemEndVTimezone: scopeTimezone,
+	itemEndVAlarm:    scopeAlarm,
+	itemEndStandard:  scopeTimezoneRule,
+	itemEndDaylight:  scopeTimezoneRule,
+}
+
+// checkNesting rejects a BEGIN delimiter in a component which can't hold
+// it and an END delimiter which doesn't close the current component
+func (p *parser) checkNesting(delim item) error {
+	if begin, ok := beginScopes[delim.typ]; ok {
+		for _, parent := range begin.parents {
+			if p.scope == parent {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("line %d: found %s inside %s, %s is not allowed there", p.line(delim), delim, scopeNames[p.scope], scopeNames[begin.scope])
+	}
+
+	if scope, ok := endScopes[delim.typ]; ok && scope != p.scope {
+		if p.scope == scopeCalendar {
+			return fmt.Errorf("line %d: found %s without a matching BEGIN", p.line(delim), delim)
+		}
+
+		return fmt.Errorf("line %d: found %s, expected END:%s", p.line(delim), delim, scopeNames[p.scope])
+	}
+
+	return nil
+}
+
+// line returns the line number of the item, in the unfolded input
+func (p *parser) line(i item) int {
+	return strings.Count(p.lex.input[:i.pos], "\n") + 1
+}
+
 // scanDelimiter switch scope and validate related component
 func (p *parser) scanDelimiter(delim item) error {
+	if err := p.checkNesting(delim); err != nil {
+		return err
+	}
+
 	if delim.typ == itemBeginVEvent {
-		if err := p.validateCalendar(p.c); err != nil {
+		if err := p.startComponent(); err != nil {
 			return err
 		}
 
 		p.v = NewEvent()
-		p.enterScope()
+		p.v.Properties = make([]*Property, 0, p.eventProperties)
+		p.rawStart = delim.pos
+		p.enterScope(scopeEvent)
 
 		if item := p.next(); item.typ != itemLineEnd {
 			return fmt.Errorf("found %s, expected CRLF", item)
@@ -210,37 +842,50 @@ func (p *parser) scanDelimiter(delim item) error {
 	}
 
 	if delim.typ == itemEndVEvent {
-		if p.scope > scopeEvent {
-			return fmt.Errorf("found %s, expeced END:VALARM", delim)
-		}
-
 		if err := p.validateEvent(p.v); err != nil {
 			return err
 		}
 
-		p.c.Events = append(p.c.Events, p.v)
 		p.leaveScope()
 
-		if item := p.next(); item.typ != itemLineEnd {
+		item := p.next()
+
+		if item.typ != itemLineEnd {
 			return fmt.Errorf("found %s, expected CRLF", item)
 		}
+
+		if p.rawSource {
+			p.v.raw = p.lex.input[p.rawStart : item.pos+len(item.val)]
+		}
+
+		if p.onEvent != nil {
+			return p.onEvent(p.v)
+		}
+
+		p.c.Events = append(p.c.Events, p.v)
+		p.c.order = append(p.c.order, p.v)
 	}
 
-	if delim.typ == itemBeginVAlarm {
-		p.a = NewAlarm()
-		p.enterScope()
+	if delim.typ == itemBeginVTodo {
+		if err := p.startComponent(); err != nil {
+			return err
+		}
+
+		p.t = NewTodo()
+		p.enterScope(scopeTodo)
 
 		if item := p.next(); item.typ != itemLineEnd {
 			return fmt.Errorf("found %s, expected CRLF", item)
 		}
 	}
 
-	if delim.typ == itemEndVAlarm {
-		if err := p.validateAlarm(p.a); err != nil {
+	if delim.typ == itemEndVTodo {
+		if err := p.validateTodo(p.t); err != nil {
 			return err
 		}
 
-		p.v.Alarms = append(p.v.Alarms, p.a)
+		p.c.Todos = append(p.c.Todos, p.t)
+		p.c.order = append(p.c.order, p.t)
 		p.leaveScope()
 
 		if item := p.next(); item.typ != itemLineEnd {
@@ -248,65 +893,326 @@ func (p *parser) scanDelimiter(delim item) error {
 		}
 	}
 
-	if delim.typ == itemEndVCalendar {
-		if p.scope > scopeCalendar {
-			return fmt.Errorf("found %s, expeced END:VEVENT", delim)
+	if delim.typ == itemBeginVJournal {
+		if err := p.startComponent(); err != nil {
+			return err
 		}
-		return errorDone
-	}
 
-	return nil
-}
+		p.j = NewJournal()
+		p.enterScope(scopeJournal)
 
-// scanContentLine parses a content-line of a calendar
-func (p *parser) scanContentLine() error {
-	name := p.next()
+		if item := p.next(); item.typ != itemLineEnd {
+			return fmt.Errorf("found %s, expected CRLF", item)
+		}
+	}
 
-	if name.typ > itemKeyword {
-		if err := p.scanDelimiter(name); err != nil {
+	if delim.typ == itemEndVJournal {
+		if err := p.validateJournal(p.j); err != nil {
 			return err
 		}
-		return p.scanContentLine()
-	}
 
-	if !isItemName(name) {
-		return fmt.Errorf("found %s, expected a \"name\" token", name)
+		p.c.Journals = append(p.c.Journals, p.j)
+		p.c.order = append(p.c.order, p.j)
+		p.leaveScope()
+
+		if item := p.next(); item.typ != itemLineEnd {
+			return fmt.Errorf("found %s, expected CRLF", item)
+		}
 	}
 
-	prop := NewProperty()
-	prop.Name = name.val
+	if delim.typ == itemBeginVFreeBusy {
+		if err := p.startComponent(); err != nil {
+			return err
+		}
 
-	if err := p.scanParams(prop); err != nil {
-		return err
-	}
+		p.fb = NewFreeBusy()
+		p.enterScope(scopeFreeBusy)
 
-	if item := p.next(); item.typ != itemColon {
-		return fmt.Errorf("found %s, expected \":\"", item)
+		if item := p.next(); item.typ != itemLineEnd {
+			return fmt.Errorf("found %s, expected CRLF", item)
+		}
 	}
 
-	value := p.next()
+	if delim.typ == itemEndVFreeBusy {
+		if err := p.validateFreeBusy(p.fb); err != nil {
+			return err
+		}
 
-	if value.typ != itemValue {
-		return fmt.Errorf("found %s, expected a value", value)
+		p.c.FreeBusys = append(p.c.FreeBusys, p.fb)
+		p.c.order = append(p.c.order, p.fb)
+		p.leaveScope()
+
+		if item := p.next(); item.typ != itemLineEnd {
+			return fmt.Errorf("found %s, expected CRLF", item)
+		}
 	}
 
-	prop.Value = value.val
+	if delim.typ == itemBeginVTimezone {
+		p.z = NewTimezone()
+		p.enterScope(scopeTimezone)
+
+		if item := p.next(); item.typ != itemLineEnd {
+			return fmt.Errorf("found %s, expected CRLF", item)
+		}
+	}
+
+	if delim.typ == itemEndVTimezone {
+		if err := p.validateTimezone(p.z); err != nil {
+			return err
+		}
+
+		p.c.Timezones = append(p.c.Timezones, p.z)
+		p.c.order = append(p.c.order, p.z)
+		p.leaveScope()
+
+		if item := p.next(); item.typ != itemLineEnd {
+			return fmt.Errorf("found %s, expected CRLF", item)
+		}
+	}
+
+	if delim.typ == itemBeginStandard || delim.typ == itemBeginDaylight {
+		p.r = NewTimezoneRule()
+		p.enterScope(scopeTimezoneRule)
+
+		if item := p.next(); item.typ != itemLineEnd {
+			return fmt.Errorf("found %s, expected CRLF", item)
+		}
+	}
+
+	if delim.typ == itemEndStandard || delim.typ == itemEndDaylight {
+		if err := p.validateTimezoneRule(p.r); err != nil {
+			return err
+		}
+
+		p.leaveScope()
+
+		if delim.typ == itemEndStandard {
+			p.z.Standard = append(p.z.Standard, p.r)
+		} else {
+			p.z.Daylight = append(p.z.Daylight, p.r)
+		}
+
+		if item := p.next(); item.typ != itemLineEnd {
+			return fmt.Errorf("found %s, expected CRLF", item)
+		}
+	}
+
+	if delim.typ == itemBeginVAlarm {
+		p.a = NewAlarm()
+		p.enterScope(scopeAlarm)
+
+		if item := p.next(); item.typ != itemLineEnd {
+			return fmt.Errorf("found %s, expected CRLF", item)
+		}
+	}
+
+	if delim.typ == itemEndVAlarm {
+		if err := p.validateAlarm(p.a); err != nil {
+			return err
+		}
+
+		p.leaveScope()
+
+		if p.scope == scopeTodo {
+			p.t.Alarms = append(p.t.Alarms, p.a)
+		} else {
+			p.v.Alarms = append(p.v.Alarms, p.a)
+		}
+
+		if item := p.next(); item.typ != itemLineEnd {
+			return fmt.Errorf("found %s, expected CRLF", item)
+		}
+	}
+
+	if delim.typ == itemEndVCalendar {
+		if err := p.validateCalendar(p.c); err != nil {
+			return err
+		}
+
+		for _, check := range p.pending {
+			if !check.relaxed(p.c.Method) {
+				return check.err
+			}
+		}
+
+		for _, u := range p.tzids {
+			if p.c.TimezoneByID(u.tzid) != nil {
+				continue
+			}
+
+			if err := p.warnf("%w", u.err); err != nil {
+				return err
+			}
+		}
+
+		if err := p.callPreamble(); err != nil {
+			return err
+		}
+
+		if err := p.validateUID(p.c); err != nil {
+			return err
+		}
+
+		return errorDone
+	}
+
+	return nil
+}
+
+// scanContentLine parses a content-line of a calendar
+func (p *parser) scanContentLine() error {
+	name := p.next()
+
+	// the known components nested in a registered one are kept raw
+	if name.typ > itemKeyword && len(p.custom) > 0 {
+		if item := p.next(); item.typ != itemLineEnd {
+			return fmt.Errorf("found %s, expected CRLF", item)
+		}
+
+		delim, comp, _ := strings.Cut(name.val, ":")
+		return p.scanComponentDelimiter(delim, comp, name)
+	}
+
+	if name.typ > itemKeyword {
+		if err := p.scanDelimiter(name); err != nil {
+			return err
+		}
+		return p.scanContentLine()
+	}
+
+	if !isItemName(name) {
+		return fmt.Errorf("found %s, expected a \"name\" token", name)
+	}
+
+	prop := NewProperty()
+	prop.Name = name.val
+
+	if err := p.scanParams(prop); err != nil {
+		return err
+	}
+
+	if item := p.next(); item.typ != itemColon {
+		return fmt.Errorf("found %s, expected \":\"", item)
+	}
+
+	value := p.next()
+
+	if value.typ != itemValue {
+		return fmt.Errorf("found %s, expected a value", value)
+	}
+
+	prop.Value = value.val
 
 	if item := p.next(); item.typ != itemLineEnd {
 		return fmt.Errorf("found %s, expected CRLF", name)
 	}
 
+	if language, ok := prop.ParamValue("LANGUAGE"); ok && !isLanguageTag(language) {
+		if err := p.warnf("line %d: invalid \"language\" %s", p.line(name), language); err != nil {
+			return err
+		}
+	}
+
+	if len(p.custom) > 0 {
+		if strings.EqualFold(prop.Name, "BEGIN") || strings.EqualFold(prop.Name, "END") {
+			return p.scanComponentDelimiter(prop.Name, prop.Value, name)
+		}
+
+		comp := p.custom[len(p.custom)-1]
+		comp.Properties = append(comp.Properties, prop)
+		return nil
+	}
+
+	if p.scope == scopeCalendar && strings.EqualFold(prop.Name, "BEGIN") {
+		if handler := componentHandler(prop.Value); handler != nil {
+			if err := p.startComponent(); err != nil {
+				return err
+			}
+
+			p.handler = handler
+			return p.scanComponentDelimiter(prop.Name, prop.Value, name)
+		}
+	}
+
+	if scopes, ok := recurrenceScopes[prop.Name]; ok && !containsScope(scopes, p.scope) {
+		if err := p.warnf("line %d: \"%s\" is not allowed in %s", p.line(name), strings.ToLower(prop.Name), scopeNames[p.scope]); err != nil {
+			return err
+		}
+	}
+
 	if p.scope == scopeCalendar {
 		p.c.Properties = append(p.c.Properties, prop)
 	} else if p.scope == scopeEvent {
 		p.v.Properties = append(p.v.Properties, prop)
+	} else if p.scope == scopeTodo {
+		p.t.Properties = append(p.t.Properties, prop)
+	} else if p.scope == scopeJournal {
+		p.j.Properties = append(p.j.Properties, prop)
 	} else if p.scope == scopeAlarm {
 		p.a.Properties = append(p.a.Properties, prop)
+	} else if p.scope == scopeFreeBusy {
+		p.fb.Properties = append(p.fb.Properties, prop)
+	} else if p.scope == scopeTimezone {
+		p.z.Properties = append(p.z.Properties, prop)
+	} else if p.scope == scopeTimezoneRule {
+		p.r.Properties = append(p.r.Properties, prop)
+	}
+
+	return nil
+}
+
+// scanComponentDelimiter enters or leaves a registered component or one of
+// its nested components. The registered component is handed to its handler
+// once it ends.
+func (p *parser) scanComponentDelimiter(delim, name string, i item) error {
+	name = strings.ToUpper(name)
+
+	if strings.EqualFold(delim, "BEGIN") {
+		p.custom = append(p.custom, &Component{Name: name})
+		return nil
+	}
+
+	comp := p.custom[len(p.custom)-1]
+
+	if name != comp.Name {
+		return fmt.Errorf("line %d: found END:%s, expected END:%s", p.line(i), name, comp.Name)
+	}
+
+	p.custom = p.custom[:len(p.custom)-1]
+
+	if len(p.custom) > 0 {
+		parent := p.custom[len(p.custom)-1]
+		parent.Components = append(parent.Components, comp)
+		return nil
+	}
+
+	if err := p.handler.ParseComponent(comp); err != nil {
+		return fmt.Errorf("error in %s: %w", comp.Name, err)
 	}
 
+	p.c.Components = append(p.c.Components, comp)
+	p.c.order = append(p.c.order, comp)
 	return nil
 }
 
+// recurrenceScopes lists the scopes allowing each recurrence property,
+// STANDARD and DAYLIGHT recur but have no exceptions
+var recurrenceScopes = map[string][]int{
+	"RRULE":  {scopeEvent, scopeTodo, scopeJournal, scopeTimezoneRule},
+	"RDATE":  {scopeEvent, scopeTodo, scopeJournal, scopeTimezoneRule},
+	"EXDATE": {scopeEvent, scopeTodo, scopeJournal},
+	"EXRULE": {scopeEvent, scopeTodo, scopeJournal},
+}
+
+func containsScope(scopes []int, scope int) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
 // scanParams parses a list of param inside a content-line
 func (p *parser) scanParams(prop *Property) error {
 	for {
@@ -333,6 +1239,18 @@ func (p *parser) scanParams(prop *Property) error {
 			return err
 		}
 
+		// a repeated param keeps the values of all its occurrences, which
+		// stay distinct when formatted
+		if previous, ok := prop.Params[paramName.val]; ok && previous != nil {
+			if previous.lengths == nil {
+				previous.lengths = []int{len(previous.Values)}
+			}
+
+			previous.Values = append(previous.Values, param.Values...)
+			previous.lengths = append(previous.lengths, len(param.Values))
+			continue
+		}
+
 		prop.Params[paramName.val] = param
 	}
 }
@@ -345,7 +1263,7 @@ func (p *parser) scanValues(param *Param) error {
 		return fmt.Errorf("found %s, expected a param-value", paramValue)
 	}
 
-	param.Values = append(param.Values, paramValue.val)
+	param.Values = append(param.Values, unescapeParamValue(paramValue.val))
 
 	for {
 		item := p.next()
@@ -361,33 +1279,130 @@ func (p *parser) scanValues(param *Param) error {
 			return fmt.Errorf("found %s, expected a param-value", paramValue)
 		}
 
-		param.Values = append(param.Values, paramValue.val)
+		param.Values = append(param.Values, unescapeParamValue(paramValue.val))
+	}
+}
+
+// startComponent reads the calendar properties parsed so far when an
+// event, todo, journal or freebusy starts, since their validation depends on
+// the calendar METHOD
+func (p *parser) startComponent() error {
+	p.readCalendar(p.c)
+	p.applyQuirks()
+	return p.callPreamble()
+}
+
+// applyQuirks enables the quirks of the producer of the calendar, see
+// WithAutoQuirks, and moves the floating times to X-WR-TIMEZONE, see
+// WithXWRTimezone
+func (p *parser) applyQuirks() {
+	if p.autoQuirks {
+		vendor := strings.ToLower(p.c.ProductInfo().Vendor)
+
+		if !p.outlookBusyStatusSet {
+			p.outlookBusyStatus = strings.Contains(vendor, "microsoft")
+		}
+
+		if !p.xwrTimezoneSet {
+			p.xwrTimezone = strings.Contains(vendor, "google")
+		}
+	}
+
+	if !p.xwrTimezone {
+		return
+	}
+
+	if prop := findProperty("X-WR-TIMEZONE", p.c.Properties); prop != nil {
+		if loc, err := loadLocation(prop.Value, p.windowsTimezones); err == nil {
+			p.location = loc
+		}
+	}
+}
+
+// callPreamble calls the preamble hook, once
+func (p *parser) callPreamble() error {
+	if p.preamble == nil || p.preambled {
+		return nil
+	}
+
+	p.preambled = true
+	return p.preamble(p.c)
+}
+
+// readCalendar sets the calendar fields from its properties, it may run
+// several times while they are parsed, the problems are left to
+// validateCalendar
+func (p *parser) readCalendar(c *Calendar) {
+	images := make([]*Image, 0)
+	categories := make([]string, 0)
+
+	for _, prop := range c.Properties {
+		switch prop.Name {
+		case "PRODID":
+			c.Prodid = prop.Value
+		case "VERSION":
+			c.Version = prop.Value
+		case "CALSCALE":
+			c.Calscale = prop.Value
+		case "METHOD":
+			c.Method = prop.Value
+		case "IMAGE":
+			if image, err := parseImage(prop); err == nil {
+				images = append(images, image)
+			}
+		case "CATEGORIES":
+			categories = append(categories, splitText(prop.Value)...)
+		case "COLOR":
+			c.Color = prop.Value
+		}
 	}
+
+	c.Images = images
+	c.Categories = categories
+
+	// Google and Apple use X-WR-CALNAME and X-WR-CALDESC for the RFC 7986
+	// NAME and DESCRIPTION
+	c.Name = calendarText(c.Properties, "NAME", "X-WR-CALNAME")
+	c.Description = calendarText(c.Properties, "DESCRIPTION", "X-WR-CALDESC")
 }
 
-// validateCalendar validate calendar props
+// validateCalendar validate calendar props, once all of them are parsed at
+// the end of the calendar
 func (p *parser) validateCalendar(c *Calendar) error {
 	requiredCount := 0
+
+	if p.strict {
+		if err := validateCalendarOrder(c.Properties); err != nil {
+			return err
+		}
+	}
+
 	for _, prop := range c.Properties {
-		if prop.Name == "PRODID" {
-			c.Prodid = prop.Value
+		if prop.Name == "PRODID" || prop.Name == "VERSION" {
 			requiredCount++
 		}
 
-		if prop.Name == "VERSION" {
-			c.Version = prop.Value
-			requiredCount++
+		if prop.Name == "CALSCALE" && prop.Value != "GREGORIAN" {
+			if err := p.warnf("unknown \"calscale\" %s", prop.Value); err != nil {
+				return err
+			}
 		}
 
-		if prop.Name == "CALSCALE" {
-			c.Calscale = prop.Value
+		if prop.Name == "METHOD" && !isMethod(prop.Value) {
+			if err := p.warnf("unknown \"method\" %s", prop.Value); err != nil {
+				return err
+			}
 		}
 
-		if prop.Name == "METHOD" {
-			c.Method = prop.Value
+		if prop.Name == "IMAGE" {
+			if _, err := parseImage(prop); err != nil {
+				return err
+			}
 		}
 	}
 
+	p.readCalendar(c)
+
 	if requiredCount != 2 {
 		return fmt.Errorf("missing either required property \"prodid / version /\"")
 	}
@@ -395,82 +1410,799 @@ func (p *parser) validateCalendar(c *Calendar) error {
 	return nil
 }
 
+// calendarText returns the unescaped value of the first property found
+// among names, in order
+func calendarText(properties []*Property, names ...string) string {
+	for _, name := range names {
+		if prop := findProperty(name, properties); prop != nil {
+			return unescapeText(prop.Value)
+		}
+	}
+	return ""
+}
+
+// isMethod checks if the value is one of the iTIP methods
+func isMethod(value string) bool {
+	for _, method := range methods {
+		if value == method {
+			return true
+		}
+	}
+	return false
+}
+
+// isLanguageTag roughly checks the syntax of a RFC 5646 language tag, a
+// primary language of 2 or 3 letters followed by subtags of 1 to 8 letters or
+// digits. Private use ("x-") and grandfathered ("i-") tags are accepted, the
+// subtags are not checked against the registry.
+func isLanguageTag(tag string) bool {
+	subtags := strings.Split(tag, "-")
+	primary := subtags[0]
+
+	if strings.EqualFold(primary, "x") || strings.EqualFold(primary, "i") {
+		if len(subtags) == 1 {
+			return false
+		}
+	} else if len(primary) < 2 || len(primary) > 3 || !isAlphanumeric(primary, false) {
+		return false
+	}
+
+	for _, subtag := range subtags[1:] {
+		if len(subtag) < 1 || len(subtag) > 8 || !isAlphanumeric(subtag, true) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isAlphanumeric checks that s is made of ASCII letters, and digits when
+// digits is set
+func isAlphanumeric(s string, digits bool) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i] | 0x20 // lower case
+
+		if (c < 'a' || c > 'z') && (!digits || s[i] < '0' || s[i] > '9') {
+			return false
+		}
+	}
+	return true
+}
+
 // validateEvent validate event props
 func (p *parser) validateEvent(v *Event) error {
+	// checked upfront so the error doesn't depend on the properties order
+	if hasProperty("DTEND", v.Properties) && hasProperty("DURATION", v.Properties) {
+		return fmt.Errorf("Either \"dtend\" or \"duration\" MAY appear")
+	}
+
 	uniqueCount := make(map[string]int)
 
 	for _, prop := range v.Properties {
+		prop, err := p.singleDate(prop)
+
+		if err != nil {
+			return err
+		}
+
 		if prop.Name == "UID" {
 			v.UID = prop.Value
 			uniqueCount["UID"]++
 		}
 
 		if prop.Name == "DTSTAMP" {
-			v.Timestamp, _ = parseDate(prop, p.location)
+			v.Timestamp, _ = parseUTCTime(prop, p.windowsTimezones)
 			uniqueCount["DTSTAMP"]++
 		}
 
 		if prop.Name == "DTSTART" {
-			v.StartDate, _ = parseDate(prop, p.location)
+			v.StartDate, _ = parseDate(prop, p.location, p.windowsTimezones)
+			v.AllDay = isDateProperty(prop)
 			uniqueCount["DTSTART"]++
 		}
 
 		if prop.Name == "DTEND" {
-			if hasProperty("DURATION", v.Properties) {
-				return fmt.Errorf("Either \"dtend\" or \"duration\" MAY appear")
-			}
-			v.EndDate, _ = parseDate(prop, p.location)
+			v.EndDate, _ = parseDate(prop, p.location, p.windowsTimezones)
 			uniqueCount["DTEND"]++
 		}
 
 		if prop.Name == "DURATION" {
-			if hasProperty("DTEND", v.Properties) {
-				return fmt.Errorf("Either \"dtend\" or \"duration\" MAY appear")
+			d, err := ParseDuration(prop.Value)
+
+			if err != nil {
+				if err := p.warnf("invalid \"duration\" %s: %w", prop.Value, err); err != nil {
+					return err
+				}
 			}
+
+			v.Duration = d
 			uniqueCount["DURATION"]++
 		}
 
 		if prop.Name == "SUMMARY" {
-			v.Summary = prop.Value
+			v.Summary = unescapeText(prop.Value)
 			uniqueCount["SUMMARY"]++
 		}
 
 		if prop.Name == "DESCRIPTION" {
-			v.Description = prop.Value
+			v.Description = unescapeText(prop.Value)
 			uniqueCount["DESCRIPTION"]++
 		}
-	}
 
-	if p.c.Method == "" && v.Timestamp.IsZero() {
-		return fmt.Errorf("missing required property \"dtstamp\"")
-	}
+		if prop.Name == "CONTACT" {
+			v.Contacts = append(v.Contacts, unescapeText(prop.Value))
+		}
 
-	if v.UID == "" {
-		return fmt.Errorf("missing required property \"uid\"")
-	}
+		if prop.Name == "GEO" {
+			geo, err := parseGeo(prop.Value)
 
-	if v.StartDate.IsZero() {
-		return fmt.Errorf("missing required property \"dtstart\"")
-	}
+			if err != nil {
+				if err := p.warnf("%v", err); err != nil {
+					return err
+				}
+			}
 
-	for key, value := range uniqueCount {
-		if value > 1 {
-			return fmt.Errorf("\"%s\" property must not occur more than once", key)
+			v.Geo = geo
+			uniqueCount["GEO"]++
 		}
-	}
 
-	if !hasProperty("DTEND", v.Properties) {
-		v.EndDate = v.StartDate.Add(time.Hour * 24) // add one day to start date
-	}
+		if prop.Name == "CATEGORIES" {
+			v.Categories = append(v.Categories, splitText(prop.Value)...)
+		}
+
+		if prop.Name == "COLOR" {
+			v.Color = prop.Value
+			uniqueCount["COLOR"]++
+		}
+
+		if prop.Name == "SEQUENCE" {
+			sequence, err := prop.Int()
+
+			if err != nil || sequence < 0 {
+				return fmt.Errorf("invalid \"sequence\" %q, expected a positive integer", prop.Value)
+			}
+
+			v.Sequence = sequence
+			uniqueCount["SEQUENCE"]++
+		}
+
+		if prop.Name == "LAST-MODIFIED" {
+			v.Modified, _ = parseUTCTime(prop, p.windowsTimezones)
+			uniqueCount["LAST-MODIFIED"]++
+		}
+
+		if prop.Name == "TRANSP" {
+			transparency := Transparency(prop.Value)
+
+			if transparency != TransparencyOpaque && transparency != TransparencyTransparent {
+				if err := p.warnf("invalid \"transp\" %s", prop.Value); err != nil {
+					return err
+				}
+			}
+
+			v.Transparency = transparency
+			uniqueCount["TRANSP"]++
+		}
+
+		if prop.Name == "X-MICROSOFT-CDO-BUSYSTATUS" && p.outlookBusyStatus {
+			status := BusyStatus(prop.Value)
+
+			switch status {
+			case BusyStatusFree, BusyStatusTentative, BusyStatusBusy, BusyStatusOOF:
+				v.BusyStatus = status
+			default:
+				if err := p.warnf("invalid \"x-microsoft-cdo-busystatus\" %s", prop.Value); err != nil {
+					return err
+				}
+			}
+		}
+
+		if prop.Name == "ATTACH" {
+			attachment, err := parseAttachment(prop)
+
+			if err != nil {
+				return err
+			}
+
+			v.Attachments = append(v.Attachments, attachment)
+		}
+
+		if prop.Name == "CONFERENCE" {
+			v.Conferences = append(v.Conferences, parseConference(prop))
+		}
+
+		if prop.Name == "ORGANIZER" {
+			v.Organizer = parseOrganizer(prop)
+			uniqueCount["ORGANIZER"]++
+		}
+
+		if prop.Name == "ATTENDEE" {
+			v.Attendees = append(v.Attendees, parseAttendee(prop))
+		}
+
+		if prop.Name == "IMAGE" {
+			image, err := parseImage(prop)
+
+			if err != nil {
+				return err
+			}
+
+			v.Images = append(v.Images, image)
+		}
+
+		if prop.Name == "STATUS" {
+			if err := p.validateStatus(prop, eventStatuses); err != nil {
+				return err
+			}
+			v.Status = Status(prop.Value)
+			uniqueCount["STATUS"]++
+		}
+	}
+
+	if v.Timestamp.IsZero() && p.relaxedDTStamp {
+		v.Timestamp = defaultTimestamp(v.StartDate)
+	}
+
+	if err := p.checkDTStamp(v.Timestamp); err != nil {
+		return err
+	}
+
+	if v.UID == "" {
+		return fmt.Errorf("missing required property \"uid\"")
+	}
+
+	// iTIP replies and cancellations may only echo the identifying properties
+	if v.StartDate.IsZero() {
+		err := p.requireUnlessMethod(fmt.Errorf("missing required property \"dtstart\""), func(method string) bool {
+			return method == MethodReply || method == MethodCancel
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if rule := findProperty("RRULE", v.Properties); rule != nil {
+		if err := checkUntil(rule, findProperty("DTSTART", v.Properties)); err != nil {
+			if err := p.warnf("%v", err); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, name := range []string{"DTSTART", "DTEND"} {
+		if err := p.checkTZID(findProperty(name, v.Properties)); err != nil {
+			return err
+		}
+	}
+
+	for _, rule := range findProperties("EXRULE", v.Properties) {
+		r, err := ParseRecurrence(rule.Value, v.StartDate.Location())
+
+		if err != nil {
+			if err := p.warnf("invalid \"exrule\": %v", err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		v.ExRules = append(v.ExRules, r)
+	}
+
+	for key, value := range uniqueCount {
+		if value > 1 {
+			return fmt.Errorf("\"%s\" property must not occur more than once", key)
+		}
+	}
+
+	// TRANSP wins over the Outlook busy status
+	if v.BusyStatus != "" && !hasProperty("TRANSP", v.Properties) {
+		if v.BusyStatus == BusyStatusFree {
+			v.Transparency = TransparencyTransparent
+		} else {
+			v.Transparency = TransparencyOpaque
+		}
+	}
+
+	if v.StartDate.IsZero() {
+		return nil
+	}
+
+	if hasProperty("DURATION", v.Properties) {
+		v.EndDate = v.StartDate.Add(v.Duration)
+	} else if !hasProperty("DTEND", v.Properties) {
+		v.EndDate = v.StartDate.Add(time.Hour * 24) // add one day to start date
+	} else if !v.EndDate.IsZero() && v.EndDate.Before(v.StartDate) {
+		// some producers swap DTSTART and DTEND, repair them in lenient mode
+		if err := p.warnf("\"dtend\" %s is before \"dtstart\", they are swapped", findProperty("DTEND", v.Properties).Value); err != nil {
+			return err
+		}
+
+		v.StartDate, v.EndDate = v.EndDate, v.StartDate
+	}
+
+	return nil
+}
+
+// checkTZID checks the TZID of a date property, if any, is known to
+// time.LoadLocation, otherwise its time is read in UTC. The TZID may still
+// be defined by a VTIMEZONE, which is checked at the end of the calendar.
+func (p *parser) checkTZID(prop *Property) error {
+	if prop == nil {
+		return nil
+	}
+
+	tzid, ok := prop.ParamValue("TZID")
+
+	if !ok {
+		return nil
+	}
+
+	if _, err := loadLocation(tzid, p.windowsTimezones); err == nil {
+		return nil
+	}
+
+	err := fmt.Errorf("unknown \"tzid\" %s of %q, neither a known location nor defined by a VTIMEZONE", tzid, strings.ToLower(prop.Name))
+	p.tzids = append(p.tzids, unresolvedTZID{tzid: tzid, err: p.errorContext(err)})
+	return nil
+}
+
+// checkDTStamp requires the DTSTAMP of a component, unless the calendar is
+// an iTIP message
+func (p *parser) checkDTStamp(t time.Time) error {
+	if !t.IsZero() {
+		return nil
+	}
+
+	return p.requireUnlessMethod(fmt.Errorf("missing required property \"dtstamp\""), func(method string) bool {
+		return method != ""
+	})
+}
+
+// requireUnlessMethod reports err unless relaxed accepts the calendar
+// METHOD. The METHOD may follow the components, without it the check is
+// postponed to the end of the calendar.
+func (p *parser) requireUnlessMethod(err error, relaxed func(method string) bool) error {
+	if relaxed(p.c.Method) {
+		return nil
+	}
+
+	if p.c.Method != "" {
+		return err
+	}
+
+	p.pending = append(p.pending, pendingCheck{err: p.errorContext(err), relaxed: relaxed})
+	return nil
+}
+
+// defaultTimestamp replaces a missing DTSTAMP by the start date of the
+// component, or the current time when it has none
+func defaultTimestamp(start time.Time) time.Time {
+	if start.IsZero() {
+		return time.Now().UTC()
+	}
+	return start.UTC()
+}
+
+// validateCalendarOrder checks the calendar properties follow the canonical
+// order, PRODID, VERSION, CALSCALE and METHOD first
+func validateCalendarOrder(properties []*Property) error {
+	rank := 0
+
+	for _, prop := range properties {
+		i := 0
+
+		for i < len(calendarOrder) && calendarOrder[i] != prop.Name {
+			i++
+		}
+
+		if i < rank {
+			return fmt.Errorf("found \"%s\" out of order, expected %s first", strings.ToLower(prop.Name), strings.Join(calendarOrder, ", "))
+		}
+
+		rank = i
+	}
+
+	return nil
+}
+
+// validateUID checks that events sharing an UID are distinguished by their RECURRENCE-ID
+func (p *parser) validateUID(c *Calendar) error {
+	seen := make(map[string]bool)
+
+	for _, v := range c.Events {
+		key := instanceKey(v)
+
+		if seen[key] {
+			if err := p.warnf("duplicate \"uid\" %s without distinct \"recurrence-id\"", v.UID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		seen[key] = true
+	}
 
 	return nil
 }
 
+// warnf reports a problem as an error in strict mode, otherwise it is
+// recorded in the calendar warnings and parsing goes on
+func (p *parser) warnf(format string, args ...interface{}) error {
+	err := fmt.Errorf(format, args...)
+
+	if p.strict {
+		return err
+	}
+
+	p.c.Warnings = append(p.c.Warnings, err)
+	return nil
+}
+
+// validateTodo validate todo props
+func (p *parser) validateTodo(t *Todo) error {
+	uniqueCount := make(map[string]int)
+
+	for _, prop := range t.Properties {
+		prop, err := p.singleDate(prop)
+
+		if err != nil {
+			return err
+		}
+
+		if prop.Name == "UID" {
+			t.UID = prop.Value
+			uniqueCount["UID"]++
+		}
+
+		if prop.Name == "DTSTAMP" {
+			t.Timestamp, _ = parseUTCTime(prop, p.windowsTimezones)
+			uniqueCount["DTSTAMP"]++
+		}
+
+		if prop.Name == "DTSTART" {
+			t.StartDate, _ = parseDate(prop, p.location, p.windowsTimezones)
+			uniqueCount["DTSTART"]++
+		}
+
+		if prop.Name == "DUE" {
+			if hasProperty("DURATION", t.Properties) {
+				return fmt.Errorf("Either \"due\" or \"duration\" MAY appear")
+			}
+			t.Due, _ = parseDate(prop, p.location, p.windowsTimezones)
+			uniqueCount["DUE"]++
+		}
+
+		if prop.Name == "DURATION" {
+			if hasProperty("DUE", t.Properties) {
+				return fmt.Errorf("Either \"due\" or \"duration\" MAY appear")
+			}
+			uniqueCount["DURATION"]++
+		}
+
+		if prop.Name == "COMPLETED" {
+			t.Completed, _ = parseUTCTime(prop, p.windowsTimezones)
+			uniqueCount["COMPLETED"]++
+		}
+
+		if prop.Name == "SUMMARY" {
+			t.Summary = unescapeText(prop.Value)
+			uniqueCount["SUMMARY"]++
+		}
+
+		if prop.Name == "DESCRIPTION" {
+			t.Description = unescapeText(prop.Value)
+			uniqueCount["DESCRIPTION"]++
+		}
+
+		if prop.Name == "PERCENT-COMPLETE" {
+			percent, err := prop.Int()
+
+			if err != nil || percent < 0 || percent > 100 {
+				return fmt.Errorf("invalid \"percent-complete\" %q, expected an integer between 0 and 100", prop.Value)
+			}
+
+			t.PercentComplete = percent
+			uniqueCount["PERCENT-COMPLETE"]++
+		}
+
+		if prop.Name == "STATUS" {
+			if err := p.validateStatus(prop, todoStatuses); err != nil {
+				return err
+			}
+			t.Status = Status(prop.Value)
+			uniqueCount["STATUS"]++
+		}
+	}
+
+	if t.Timestamp.IsZero() && p.relaxedDTStamp {
+		t.Timestamp = defaultTimestamp(t.StartDate)
+	}
+
+	if err := p.checkDTStamp(t.Timestamp); err != nil {
+		return err
+	}
+
+	if t.UID == "" {
+		return fmt.Errorf("missing required property \"uid\"")
+	}
+
+	for key, value := range uniqueCount {
+		if value > 1 {
+			return fmt.Errorf("\"%s\" property must not occur more than once", key)
+		}
+	}
+
+	return nil
+}
+
+// validateJournal validate journal props
+func (p *parser) validateJournal(j *Journal) error {
+	uniqueCount := make(map[string]int)
+
+	for _, prop := range j.Properties {
+		prop, err := p.singleDate(prop)
+
+		if err != nil {
+			return err
+		}
+
+		if prop.Name == "UID" {
+			j.UID = prop.Value
+			uniqueCount["UID"]++
+		}
+
+		if prop.Name == "DTSTAMP" {
+			j.Timestamp, _ = parseUTCTime(prop, p.windowsTimezones)
+			uniqueCount["DTSTAMP"]++
+		}
+
+		if prop.Name == "DTSTART" {
+			j.StartDate, _ = parseDate(prop, p.location, p.windowsTimezones)
+			uniqueCount["DTSTART"]++
+		}
+
+		if prop.Name == "SUMMARY" {
+			j.Summary = unescapeText(prop.Value)
+			uniqueCount["SUMMARY"]++
+		}
+
+		if prop.Name == "DESCRIPTION" {
+			j.Description = unescapeText(prop.Value)
+		}
+
+		if prop.Name == "STATUS" {
+			if err := p.validateStatus(prop, journalStatuses); err != nil {
+				return err
+			}
+			j.Status = Status(prop.Value)
+			uniqueCount["STATUS"]++
+		}
+	}
+
+	if j.Timestamp.IsZero() && p.relaxedDTStamp {
+		j.Timestamp = defaultTimestamp(j.StartDate)
+	}
+
+	if err := p.checkDTStamp(j.Timestamp); err != nil {
+		return err
+	}
+
+	if j.UID == "" {
+		return fmt.Errorf("missing required property \"uid\"")
+	}
+
+	for key, value := range uniqueCount {
+		if value > 1 {
+			return fmt.Errorf("\"%s\" property must not occur more than once", key)
+		}
+	}
+
+	return nil
+}
+
+// validateFreeBusy validate freebusy props
+func (p *parser) validateFreeBusy(fb *FreeBusy) error {
+	uniqueCount := make(map[string]int)
+
+	for _, prop := range fb.Properties {
+		prop, err := p.singleDate(prop)
+
+		if err != nil {
+			return err
+		}
+
+		if prop.Name == "UID" {
+			fb.UID = prop.Value
+			uniqueCount["UID"]++
+		}
+
+		if prop.Name == "DTSTAMP" {
+			fb.Timestamp, _ = parseUTCTime(prop, p.windowsTimezones)
+			uniqueCount["DTSTAMP"]++
+		}
+
+		if prop.Name == "DTSTART" {
+			fb.StartDate, _ = parseDate(prop, p.location, p.windowsTimezones)
+			uniqueCount["DTSTART"]++
+		}
+
+		if prop.Name == "DTEND" {
+			fb.EndDate, _ = parseDate(prop, p.location, p.windowsTimezones)
+			uniqueCount["DTEND"]++
+		}
+
+		if prop.Name == "FREEBUSY" {
+			periods, err := parsePeriods(prop, p.location, p.windowsTimezones)
+
+			if err != nil {
+				return err
+			}
+
+			fb.Periods = append(fb.Periods, periods...)
+		}
+	}
+
+	if err := p.checkDTStamp(fb.Timestamp); err != nil {
+		return err
+	}
+
+	if fb.UID == "" {
+		return fmt.Errorf("missing required property \"uid\"")
+	}
+
+	for key, value := range uniqueCount {
+		if value > 1 {
+			return fmt.Errorf("\"%s\" property must not occur more than once", key)
+		}
+	}
+
+	return nil
+}
+
+// singleDateProperties lists the date properties which only allow a single
+// value, unlike RDATE or EXDATE
+var singleDateProperties = map[string]bool{
+	"DTSTAMP":       true,
+	"DTSTART":       true,
+	"DTEND":         true,
+	"DUE":           true,
+	"COMPLETED":     true,
+	"CREATED":       true,
+	"LAST-MODIFIED": true,
+	"RECURRENCE-ID": true,
+	"TRIGGER":       true,
+}
+
+// singleDate detects a list of values in a single-valued date property, and
+// a date in the ISO 8601 extended form, 2020-02-11T09:00:00Z for instance.
+// Both are errors in strict mode, otherwise a copy of the property holding
+// only the first value, in the basic form, is returned along with a warning.
+func (p *parser) singleDate(prop *Property) (*Property, error) {
+	if !singleDateProperties[prop.Name] {
+		return prop, nil
+	}
+
+	first, _, found := strings.Cut(prop.Value, ",")
+
+	if found {
+		if err := p.warnf("%q has multiple values %s, expected a single date", strings.ToLower(prop.Name), prop.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	basic, extended := basicDate(first)
+
+	if extended {
+		if err := p.warnf("%q %s is in the extended form, expected %s", strings.ToLower(prop.Name), first, basic); err != nil {
+			return nil, err
+		}
+	}
+
+	if !found && !extended {
+		return prop, nil
+	}
+
+	single := *prop
+	single.Value = basic
+
+	return &single, nil
+}
+
+// basicDate converts a DATE or DATE-TIME in the ISO 8601 extended form, with
+// dashes between the date parts or colons between the time parts, to the
+// basic form of RFC 5545. It reports whether value was in the extended form.
+func basicDate(value string) (string, bool) {
+	switch {
+	case len(value) >= 10 && isDigits(value[:4]) && value[4] == '-' && value[7] == '-':
+		value = value[:4] + value[5:7] + value[8:10] + strings.ReplaceAll(value[10:], ":", "")
+		return value, true
+	case len(value) >= 12 && isDigits(value[:8]) && value[8] == 'T' && value[11] == ':':
+		return value[:9] + strings.ReplaceAll(value[9:], ":", ""), true
+	default:
+		return value, false
+	}
+}
+
+// validateTimezone validate timezone props
+func (p *parser) validateTimezone(z *Timezone) error {
+	for _, prop := range z.Properties {
+		if prop.Name == "TZID" {
+			if z.TZID != "" {
+				return fmt.Errorf("\"tzid\" property must not occur more than once")
+			}
+			z.TZID = prop.Value
+		}
+	}
+
+	if z.TZID == "" {
+		return fmt.Errorf("missing required property \"tzid\"")
+	}
+
+	if len(z.Standard) == 0 && len(z.Daylight) == 0 {
+		return fmt.Errorf("timezone %s requires at least one STANDARD or DAYLIGHT", z.TZID)
+	}
+
+	return nil
+}
+
+// validateTimezoneRule validate standard and daylight props
+func (p *parser) validateTimezoneRule(r *TimezoneRule) error {
+	for _, name := range []string{"DTSTART", "TZOFFSETFROM", "TZOFFSETTO"} {
+		if !hasProperty(name, r.Properties) {
+			return fmt.Errorf("missing required property \"%s\"", strings.ToLower(name))
+		}
+	}
+
+	for _, prop := range r.Properties {
+		if prop.Name == "DTSTART" {
+			r.StartDate, _ = parseDate(prop, p.location, p.windowsTimezones)
+		}
+
+		if prop.Name == "TZOFFSETFROM" || prop.Name == "TZOFFSETTO" {
+			offset, err := ParseUTCOffset(prop.Value)
+
+			if err != nil {
+				return err
+			}
+
+			if prop.Name == "TZOFFSETFROM" {
+				r.OffsetFrom = offset
+			} else {
+				r.OffsetTo = offset
+			}
+		}
+
+		if prop.Name == "TZNAME" && r.Name == "" {
+			r.Name = prop.Value
+		}
+	}
+
+	return nil
+}
+
+// validateStatus checks the STATUS value is allowed for the component
+func (p *parser) validateStatus(prop *Property, allowed []Status) error {
+	for _, status := range allowed {
+		if Status(prop.Value) == status {
+			return nil
+		}
+	}
+
+	return p.warnf("invalid \"status\" %s for this component", prop.Value)
+}
+
 // validateAlarm validate alarm props
 func (p *parser) validateAlarm(a *Alarm) error {
 	requiredCount := 0
 	uniqueCount := make(map[string]int)
 	for _, prop := range a.Properties {
+		prop, err := p.singleDate(prop)
+
+		if err != nil {
+			return err
+		}
+
 		if prop.Name == "ACTION" {
 			a.Action = prop.Value
 			requiredCount++
@@ -478,10 +2210,34 @@ func (p *parser) validateAlarm(a *Alarm) error {
 		}
 
 		if prop.Name == "TRIGGER" {
+			if prop.ValueType() == ValueDateTime {
+				if _, ok := prop.Params["RELATED"]; ok {
+					return fmt.Errorf("\"related\" param is not allowed on an absolute \"trigger\"")
+				}
+
+				date, err := parseDate(prop, p.location, p.windowsTimezones)
+
+				if err != nil {
+					return fmt.Errorf("invalid absolute \"trigger\" %s: %v", prop.Value, err)
+				}
+
+				a.TriggerDate = date
+			}
+
 			a.Trigger = prop.Value
 			requiredCount++
 			uniqueCount["TRIGGER"]++
 		}
+
+		if prop.Name == "ATTACH" {
+			attachment, err := parseAttachment(prop)
+
+			if err != nil {
+				return err
+			}
+
+			a.Attachments = append(a.Attachments, attachment)
+		}
 	}
 
 	if requiredCount != 2 {
@@ -507,14 +2263,329 @@ func hasProperty(name string, properties []*Property) bool {
 	return false
 }
 
-// parseDate transform an ical date property into a time.Time
-func parseDate(prop *Property, l *time.Location) (time.Time, error) {
+// findProperty returns the first property with the given name, or nil
+func findProperty(name string, properties []*Property) *Property {
+	for _, prop := range properties {
+		if name == prop.Name {
+			return prop
+		}
+	}
+	return nil
+}
+
+// findProperties returns all the properties with the given name
+func findProperties(name string, properties []*Property) []*Property {
+	found := make([]*Property, 0)
+	for _, prop := range properties {
+		if name == prop.Name {
+			found = append(found, prop)
+		}
+	}
+	return found
+}
+
+// instanceKey identifies an event by its UID and RECURRENCE-ID
+func instanceKey(v *Event) string {
+	if prop := findProperty("RECURRENCE-ID", v.Properties); prop != nil {
+		return v.UID + "/" + prop.Value
+	}
+	return v.UID
+}
+
+// isDateProperty checks if a date property holds a DATE rather than a DATE-TIME
+func isDateProperty(prop *Property) bool {
+	return prop != nil && len(prop.Value) == len(dateLayout)
+}
+
+// splitText splits a list of escaped TEXT values on the unescaped commas
+// and unescapes each of them
+func splitText(value string) []string {
+	values := make([]string, 0, 1)
+	start := 0
+	escaped := false
+
+	for i := 0; i < len(value); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case value[i] == '\\':
+			escaped = true
+		case value[i] == ',':
+			values = append(values, unescapeText(value[start:i]))
+			start = i + 1
+		}
+	}
+
+	return append(values, unescapeText(value[start:]))
+}
+
+// unescapeParamValue decodes the RFC 6868 caret escaping of a param value,
+// a caret followed by any other character is left as is
+//
+// param-value-escape = "^n" / "^^" / "^'"
+func unescapeParamValue(value string) string {
+	if !strings.Contains(value, "^") {
+		return value
+	}
+
+	var b strings.Builder
+	b.Grow(len(value))
+
+	for i := 0; i < len(value); i++ {
+		if value[i] != '^' || i+1 == len(value) {
+			b.WriteByte(value[i])
+			continue
+		}
+
+		switch value[i+1] {
+		case 'n':
+			b.WriteByte('\n')
+		case '^':
+			b.WriteByte('^')
+		case '\'':
+			b.WriteByte('"')
+		default:
+			b.WriteByte('^')
+			continue
+		}
+
+		i++
+	}
+
+	return b.String()
+}
+
+// unescapeText converts an escaped TEXT value to its raw form
+//
+// ESCAPED-CHAR = ("\\" / "\;" / "\," / "\N" / "\n")
+func unescapeText(value string) string {
+	if !strings.Contains(value, "\\") {
+		return value
+	}
+
+	var b strings.Builder
+	b.Grow(len(value))
+	escaped := false
+
+	for _, r := range value {
+		if !escaped {
+			if r == '\\' {
+				escaped = true
+			} else {
+				b.WriteRune(r)
+			}
+			continue
+		}
+
+		switch r {
+		case 'n', 'N':
+			b.WriteRune('\n')
+		default:
+			b.WriteRune(r)
+		}
+		escaped = false
+	}
+
+	return b.String()
+}
+
+// parseConference transform a CONFERENCE property into a Conference
+func parseConference(prop *Property) Conference {
+	conference := Conference{
+		URI:      prop.Value,
+		Features: make([]string, 0),
+	}
+
+	if feature, ok := prop.Params["FEATURE"]; ok && feature != nil {
+		conference.Features = append(conference.Features, feature.Values...)
+	}
+
+	conference.Label, _ = prop.ParamValue("LABEL")
+
+	return conference
+}
+
+// parseOrganizer transform an ORGANIZER property into an Organizer
+func parseOrganizer(prop *Property) *Organizer {
+	organizer := &Organizer{
+		Address:    prop.Value,
+		CommonName: commonName(prop),
+	}
+
+	organizer.Dir, _ = prop.ParamValue("DIR")
+	organizer.SentBy, _ = prop.ParamValue("SENT-BY")
+
+	return organizer
+}
+
+// parseAttendee transform an ATTENDEE property into an Attendee
+func parseAttendee(prop *Property) *Attendee {
+	attendee := &Attendee{
+		Address:       prop.Value,
+		CommonName:    commonName(prop),
+		DelegatedFrom: prop.ParamValues("DELEGATED-FROM"),
+		DelegatedTo:   prop.ParamValues("DELEGATED-TO"),
+		Member:        prop.ParamValues("MEMBER"),
+	}
+
+	attendee.Dir, _ = prop.ParamValue("DIR")
+	attendee.CUType = CUTypeIndividual
+
+	if cutype, ok := prop.ParamValue("CUTYPE"); ok {
+		attendee.CUType = CUType(strings.ToUpper(cutype))
+	}
+
+	return attendee
+}
+
+// commonName returns the CN param of the property. An unquoted CN is split
+// on commas by the lexer, glue it back.
+func commonName(prop *Property) string {
+	return strings.Join(prop.ParamValues("CN"), ",")
+}
+
+// parsePeriods transform a FREEBUSY property into its list of periods
+//
+// period = period-explicit / period-start
+// period-explicit = date-time "/" date-time
+// period-start = date-time "/" dur-value
+func parsePeriods(prop *Property, l *time.Location, windows bool) ([]*Period, error) {
+	fbtype := FreeBusyBusy
+
+	if value, ok := prop.ParamValue("FBTYPE"); ok {
+		fbtype = FreeBusyType(value)
+	}
+
+	periods := make([]*Period, 0)
+
+	for _, value := range strings.Split(prop.Value, ",") {
+		start, end, found := strings.Cut(value, "/")
+
+		if !found {
+			return nil, fmt.Errorf("invalid period %s, expected start/end", value)
+		}
+
+		period := &Period{Type: fbtype}
+		var err error
+
+		if period.Start, err = parseDate(&Property{Value: start, Params: prop.Params}, l, windows); err != nil {
+			return nil, fmt.Errorf("invalid period start %s", start)
+		}
+
+		if strings.HasPrefix(end, "P") || strings.HasPrefix(end, "+") || strings.HasPrefix(end, "-") {
+			d, err := ParseDuration(end)
+
+			if err != nil {
+				return nil, err
+			}
+
+			period.End = period.Start.Add(d)
+		} else if period.End, err = parseDate(&Property{Value: end, Params: prop.Params}, l, windows); err != nil {
+			return nil, fmt.Errorf("invalid period end %s", end)
+		}
+
+		periods = append(periods, period)
+	}
+
+	return periods, nil
+}
+
+// parseImage transform an IMAGE property into an Image
+//
+// image = "IMAGE" imageparam ( ";" "VALUE" "=" "URI" ":" uri ) / ( ";" "ENCODING" "=" "BASE64" ";" "VALUE" "=" "BINARY" ":" binary )
+func parseImage(prop *Property) (*Image, error) {
+	attachment, err := parseAttachment(prop)
+
+	if err != nil {
+		return nil, err
+	}
+
+	image := &Image{
+		Attachment: *attachment,
+		Display:    make([]string, 0),
+	}
+
+	if display, ok := prop.Params["DISPLAY"]; ok && display != nil {
+		image.Display = append(image.Display, display.Values...)
+	}
+
+	return image, nil
+}
+
+// parseAttachment transform an ATTACH property into an Attachment
+//
+// attach = "ATTACH" attachparam ( ":" uri ) / ( ";" "ENCODING" "=" "BASE64" ";" "VALUE" "=" "BINARY" ":" binary )
+func parseAttachment(prop *Property) (*Attachment, error) {
+	attachment := &Attachment{}
+	attachment.MimeType, _ = prop.MediaType()
+
+	if encoding, _ := prop.ParamValue("ENCODING"); encoding == "BASE64" {
+		data, err := base64.StdEncoding.DecodeString(prop.Value)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 binary in %s: %v", prop.Name, err)
+		}
+
+		attachment.Data = data
+		return attachment, nil
+	}
+
+	attachment.URI = prop.Value
+	return attachment, nil
+}
+
+// parseUTCTime parses a timestamp property, such as DTSTAMP, LAST-MODIFIED
+// or COMPLETED, whose value is a UTC date-time. The forms accepted by
+// parseDate are accepted too, a floating value being read as UTC.
+func parseUTCTime(prop *Property, windows bool) (time.Time, error) {
+	t, err := parseDate(prop, time.UTC, windows)
+	return t.UTC(), err
+}
+
+// loadLocation resolves a TZID to a location. RFC 5545 reserves the "/"
+// prefix for globally unique TZIDs, the IANA name which may follow it is
+// resolved too, as are the Windows timezone names of Outlook when windows is
+// set.
+func loadLocation(tzid string, windows bool) (*time.Location, error) {
+	loc, err := time.LoadLocation(tzid)
+
+	if err == nil {
+		return loc, nil
+	}
+
+	if name, ok := windowsZones[tzid]; ok && windows {
+		return time.LoadLocation(name)
+	}
+
+	if strings.HasPrefix(tzid, "/") {
+		return time.LoadLocation(strings.TrimPrefix(tzid, "/"))
+	}
+
+	return loc, err
+}
+
+// parseDate transform an ical date property into a time.Time, windows
+// resolves the Windows timezone names used as TZID, see loadLocation
+func parseDate(prop *Property, l *time.Location, windows bool) (time.Time, error) {
 	if strings.HasSuffix(prop.Value, "Z") {
 		return time.Parse(dateTimeLayoutUTC, prop.Value)
 	}
 
-	if tz, ok := prop.Params["TZID"]; ok {
-		loc, err := time.LoadLocation(tz.Values[0])
+	// Some producers append a numeric UTC offset, possibly with seconds,
+	// instead of the "Z" suffix. Fractional seconds are accepted by all the
+	// layouts.
+	if strings.LastIndexAny(prop.Value, "+-") > 0 {
+		t, err := time.Parse(dateTimeLayoutOffset, prop.Value)
+
+		if err != nil {
+			t, err = time.Parse(dateTimeLayoutOffsetSeconds, prop.Value)
+		}
+
+		return t.UTC(), err
+	}
+
+	if tzid, ok := prop.ParamValue("TZID"); ok {
+		loc, err := loadLocation(tzid, windows)
 
 		// In case we are not able to load TZID location we default to UTC
 		if err != nil {
@@ -530,16 +2601,11 @@ func parseDate(prop *Property, l *time.Location) (time.Time, error) {
 
 	layout := dateTimeLayoutLocalized
 
-	if val, ok := prop.Params["VALUE"]; ok {
-		switch val.Values[0] {
-		case "DATE":
-			layout = dateLayout
+	if prop.ValueType() == ValueDate {
+		layout = dateLayout
 
-			// Handle malformed DATE entries that use DATE-TIME format
-			if len(prop.Value) == len(dateTimeLayoutLocalized) {
-				layout = dateTimeLayoutLocalized
-			}
-		case "DATE-TIME":
+		// Handle malformed DATE entries that use DATE-TIME format
+		if len(prop.Value) == len(dateTimeLayoutLocalized) {
 			layout = dateTimeLayoutLocalized
 		}
 	}
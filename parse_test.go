@@ -1,14 +1,20 @@
 package ical
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
 
-var calendarList = []string{"fixtures/example.ics", "fixtures/with-alarm.ics", "fixtures/facebookbirthday.ics", "fixtures/malformed-date.ics"}
+var calendarList = []string{"fixtures/example.ics", "fixtures/with-alarm.ics", "fixtures/facebookbirthday.ics", "fixtures/malformed-date.ics", "fixtures/todo.ics", "fixtures/itip-request.ics", "fixtures/itip-cancel.ics", "fixtures/itip-reply.ics", "fixtures/itip-method-last.ics", "fixtures/vtimezone.ics", "fixtures/freebusy.ics"}
 
 func TestParse(t *testing.T) {
 	for _, filename := range calendarList {
@@ -24,18 +30,41 @@ func TestParse(t *testing.T) {
 
 func BenchmarkParse(b *testing.B) {
 	for _, filename := range calendarList {
-		file, _ := os.Open(filename)
-		info, _ := file.Stat()
+		ics, _ := os.ReadFile(filename)
 
 		b.Run(filename, func(b *testing.B) {
-			b.SetBytes(info.Size())
+			b.SetBytes(int64(len(ics)))
 			for n := 0; n < b.N; n++ {
-				_, _ = Parse(file, nil)
+				_, _ = Parse(bytes.NewReader(ics), nil)
 			}
 		})
 	}
 }
 
+func BenchmarkParse_large(b *testing.B) {
+	var buf bytes.Buffer
+	buf.WriteString("BEGIN:VCALENDAR\r\nPRODID:-//xyz Corp//NONSGML PDA Calendar Version 1.0//EN\r\nVERSION:2.0\r\n")
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&buf, "BEGIN:VEVENT\r\nDTSTAMP:19960704T120000Z\r\nUID:uid%d@example.com\r\n", i)
+		buf.WriteString("DTSTART:19960918T143000Z\r\nDTEND:19960920T220000Z\r\nSTATUS:CONFIRMED\r\n")
+		buf.WriteString("SUMMARY;LANGUAGE=en:Networld+Interop Conference\r\n")
+		buf.WriteString("DESCRIPTION:Networld+Interop Conference and Exhibit\\nAtlanta World Congress\r\n  Center\\n Atlanta\\, Georgia\r\n")
+		buf.WriteString("END:VEVENT\r\n")
+	}
+	buf.WriteString("END:VCALENDAR\r\n")
+	ics := buf.Bytes()
+
+	b.SetBytes(int64(len(ics)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		if _, err := Parse(bytes.NewReader(ics), nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func Test_parseDate(t *testing.T) {
 	loc, _ := time.LoadLocation("America/New_York")
 	type args struct {
@@ -117,6 +146,54 @@ func Test_parseDate(t *testing.T) {
 			},
 			want: time.Date(1998, time.January, 19, 7, 0, 0, 0, time.Local),
 		},
+		{
+			name: "Property with repeated value type",
+			args: args{
+				prop: &Property{
+					Name: "DTSTART",
+					Params: map[string]*Param{
+						"VALUE": {
+							Values: []string{"DATE-TIME", "DATE"},
+						},
+					},
+					Value: "19980119",
+				},
+				l: time.Local,
+			},
+			want: time.Date(1998, time.January, 19, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name: "Property with empty value type",
+			args: args{
+				prop: &Property{
+					Name: "DTSTART",
+					Params: map[string]*Param{
+						"VALUE": {
+							Values: []string{},
+						},
+					},
+					Value: "19980119T070000",
+				},
+				l: time.Local,
+			},
+			want: time.Date(1998, time.January, 19, 7, 0, 0, 0, time.Local),
+		},
+		{
+			name: "Datetime with empty timezone",
+			args: args{
+				prop: &Property{
+					Name: "DTSTART",
+					Params: map[string]*Param{
+						"TZID": {
+							Values: []string{},
+						},
+					},
+					Value: "19980119T020000",
+				},
+				l: time.Local,
+			},
+			want: time.Date(1998, time.January, 19, 2, 0, 0, 0, time.Local),
+		},
 		{
 			name: "Datetime with bad timezone",
 			args: args{
@@ -136,7 +213,7 @@ func Test_parseDate(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := parseDate(tt.args.prop, tt.args.l)
+			got, err := parseDate(tt.args.prop, tt.args.l, true)
 			if (err != nil) != false {
 				t.Errorf("parseDate() error = %v, wantErr %v", err, false)
 				return
@@ -147,3 +224,1197 @@ func Test_parseDate(t *testing.T) {
 		})
 	}
 }
+
+func TestParse_duplicateUID(t *testing.T) {
+	file, _ := os.Open("fixtures/example.ics")
+	c, err := Parse(file, nil)
+	file.Close()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.Warnings) != 3 {
+		t.Errorf("got %d warnings, want 3", len(c.Warnings))
+	}
+
+	file, _ = os.Open("fixtures/example.ics")
+	_, err = Parse(file, nil, WithStrict(true))
+	file.Close()
+
+	if err == nil {
+		t.Error("expected an error on duplicate UID in strict mode")
+	}
+}
+
+func TestParse_todo(t *testing.T) {
+	file, _ := os.Open("fixtures/todo.ics")
+	c, err := Parse(file, nil)
+	file.Close()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.Todos) != 1 {
+		t.Fatalf("got %d todos, want 1", len(c.Todos))
+	}
+
+	todo := c.Todos[0]
+
+	if todo.PercentComplete != 40 {
+		t.Errorf("PercentComplete = %d, want 40", todo.PercentComplete)
+	}
+
+	if len(todo.Alarms) != 1 {
+		t.Errorf("got %d alarms, want 1", len(todo.Alarms))
+	}
+}
+
+func TestParse_invalidPercentComplete(t *testing.T) {
+	for _, value := range []string{"101", "-1", "half"} {
+		ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+			"BEGIN:VTODO\r\nDTSTAMP:19980130T134500Z\r\nUID:uid4@example.com\r\n" +
+			"PERCENT-COMPLETE:" + value + "\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+
+		if _, err := Parse(strings.NewReader(ics), nil); err == nil {
+			t.Errorf("expected an error for PERCENT-COMPLETE:%s", value)
+		}
+	}
+}
+
+func TestParse_todoDueAndDuration(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VTODO\r\nDTSTAMP:19980130T134500Z\r\nUID:uid4@example.com\r\n" +
+		"DTSTART:19980415T000000Z\r\nDUE:19980416T000000Z\r\nDURATION:PT1H\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+
+	if _, err := Parse(strings.NewReader(ics), nil); err == nil {
+		t.Error("expected an error when both DUE and DURATION appear")
+	}
+}
+
+func TestParse_dtendAndDuration(t *testing.T) {
+	tests := []struct {
+		name       string
+		properties string
+	}{
+		{name: "DTEND first", properties: "DTEND:19980415T010000Z\r\nDURATION:PT1H\r\n"},
+		{name: "DURATION first", properties: "DURATION:PT1H\r\nDTEND:19980415T010000Z\r\n"},
+		{name: "Invalid DURATION first", properties: "DURATION:1H\r\nDTEND:19980415T010000Z\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+				"BEGIN:VEVENT\r\nDTSTAMP:19980130T134500Z\r\nDTSTART:19980415T000000Z\r\n" + tt.properties +
+				"UID:uid5@example.com\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+			want := "error in VEVENT (UID=uid5@example.com): Either \"dtend\" or \"duration\" MAY appear"
+
+			_, err := Parse(strings.NewReader(ics), nil, WithStrict(true))
+			if err == nil || err.Error() != want {
+				t.Errorf("Parse() error = %v, want %q", err, want)
+			}
+		})
+	}
+}
+
+func TestParse_language(t *testing.T) {
+	tests := []struct {
+		language string
+		wantErr  bool
+	}{
+		{language: "en"},
+		{language: "fr-CA"},
+		{language: "zh-Hant"},
+		{language: "es-419"},
+		{language: "x-klingon"},
+		{language: "english", wantErr: true},
+		{language: "e", wantErr: true},
+		{language: "en_US", wantErr: true},
+		{language: "en-", wantErr: true},
+		{language: "en-toolongtag", wantErr: true},
+		{language: "x", wantErr: true},
+		{language: "12", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+				"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:uid@example.com\r\nDTSTART:20200211T100000Z\r\n" +
+				"SUMMARY;LANGUAGE=" + tt.language + ":Meeting\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+			_, err := Parse(strings.NewReader(ics), nil, WithStrict(true))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			c, err := Parse(strings.NewReader(ics), nil)
+			if err != nil {
+				t.Fatalf("Parse() error = %v in lenient mode", err)
+			}
+
+			if len(c.Warnings) > 0 != tt.wantErr {
+				t.Errorf("Warnings = %v, want a warning %v", c.Warnings, tt.wantErr)
+			}
+
+			var buf bytes.Buffer
+			if err := Format(&buf, c); err != nil {
+				t.Fatal(err)
+			}
+
+			if want := "SUMMARY;LANGUAGE=" + tt.language + ":Meeting\r\n"; !strings.Contains(buf.String(), want) {
+				t.Errorf("Format() = %q, want it to contain %q", buf.String(), want)
+			}
+		})
+	}
+}
+
+func TestParse_autoQuirks(t *testing.T) {
+	google := "-//Google Inc//Google Calendar 70.9054//EN"
+	outlook := "-//Microsoft Corporation//Outlook 16.0 MIMEDIR//EN"
+
+	tests := []struct {
+		name     string
+		prodID   string
+		opts     []Option
+		wantLoc  string
+		wantBusy BusyStatus
+	}{
+		{name: "Disabled", prodID: google, wantLoc: "UTC"},
+		{name: "Google", prodID: google, opts: []Option{WithAutoQuirks(true)}, wantLoc: "America/New_York"},
+		{name: "Outlook", prodID: outlook, opts: []Option{WithAutoQuirks(true)}, wantLoc: "UTC", wantBusy: BusyStatusFree},
+		{name: "Other", prodID: "-//xyz Corp//NONSGML PDA Calendar Version 1.0//EN", opts: []Option{WithAutoQuirks(true)}, wantLoc: "UTC"},
+		{name: "Explicitly disabled", prodID: google, opts: []Option{WithAutoQuirks(true), WithXWRTimezone(false)}, wantLoc: "UTC"},
+		{name: "Explicitly enabled", prodID: outlook, opts: []Option{WithXWRTimezone(true), WithAutoQuirks(true)}, wantLoc: "America/New_York", wantBusy: BusyStatusFree},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ics := "BEGIN:VCALENDAR\r\nPRODID:" + tt.prodID + "\r\nVERSION:2.0\r\nX-WR-TIMEZONE:America/New_York\r\n" +
+				"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:uid@example.com\r\nDTSTART:20200211T100000\r\n" +
+				"X-MICROSOFT-CDO-BUSYSTATUS:FREE\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+			c, err := Parse(strings.NewReader(ics), time.UTC, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			v := c.Events[0]
+
+			if got := v.StartDate.Location().String(); got != tt.wantLoc || v.StartDate.Hour() != 10 {
+				t.Errorf("StartDate = %v, want 10:00 in %s", v.StartDate, tt.wantLoc)
+			}
+
+			if v.BusyStatus != tt.wantBusy {
+				t.Errorf("BusyStatus = %q, want %q", v.BusyStatus, tt.wantBusy)
+			}
+		})
+	}
+}
+
+func TestParse_swappedDates(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:uid@example.com\r\n" +
+		"DTSTART:20200211T110000Z\r\nDTEND:20200211T100000Z\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	want := "\"dtend\" 20200211T100000Z is before \"dtstart\", they are swapped"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := c.Events[0]
+
+	if !v.StartDate.Equal(time.Date(2020, time.February, 11, 10, 0, 0, 0, time.UTC)) || !v.EndDate.Equal(time.Date(2020, time.February, 11, 11, 0, 0, 0, time.UTC)) {
+		t.Errorf("StartDate, EndDate = %v, %v, want them swapped", v.StartDate, v.EndDate)
+	}
+
+	if len(c.Warnings) != 1 || c.Warnings[0].Error() != want {
+		t.Errorf("Warnings = %v, want %q", c.Warnings, want)
+	}
+
+	_, err = Parse(strings.NewReader(ics), nil, WithStrict(true))
+	if err == nil || !strings.HasSuffix(err.Error(), want) {
+		t.Errorf("Parse() error = %v, want %q in strict mode", err, want)
+	}
+}
+
+func TestParse_unresolvedTZID(t *testing.T) {
+	vtimezone := "BEGIN:VTIMEZONE\r\nTZID:Custom\r\nBEGIN:STANDARD\r\nDTSTART:19700101T000000\r\n" +
+		"TZOFFSETFROM:+0100\r\nTZOFFSETTO:+0100\r\nEND:STANDARD\r\nEND:VTIMEZONE\r\n"
+
+	tests := []struct {
+		name    string
+		tzid    string
+		extra   string
+		wantErr bool
+	}{
+		{name: "IANA", tzid: "Europe/Paris", wantErr: false},
+		{name: "Defined after", tzid: "Custom", extra: vtimezone, wantErr: false},
+		{name: "Unknown", tzid: "Custom", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+				"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:uid@example.com\r\n" +
+				"DTSTART;TZID=" + tt.tzid + ":20200211T100000\r\nEND:VEVENT\r\n" + tt.extra + "END:VCALENDAR\r\n"
+			want := "unknown \"tzid\" Custom of \"dtstart\", neither a known location nor defined by a VTIMEZONE"
+
+			c, err := Parse(strings.NewReader(ics), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := len(c.Warnings) == 1 && strings.HasSuffix(c.Warnings[0].Error(), want); got != tt.wantErr {
+				t.Errorf("Warnings = %v, want %q: %v", c.Warnings, want, tt.wantErr)
+			}
+
+			_, err = Parse(strings.NewReader(ics), nil, WithStrict(true))
+			if (err != nil) != tt.wantErr || (err != nil && !strings.HasSuffix(err.Error(), want)) {
+				t.Errorf("Parse() error = %v, want %q: %v in strict mode", err, want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseMany(t *testing.T) {
+	valid := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:uid@example.com\r\nDTSTART:20200211T100000\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+	invalid := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nEND:VCALENDAR\r\n"
+
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	calendars, errs := ParseMany([]io.Reader{strings.NewReader(invalid), strings.NewReader(valid)}, loc)
+
+	if len(calendars) != 2 || len(errs) != 2 {
+		t.Fatalf("ParseMany() = %d calendars, %d errors, want 2 of each", len(calendars), len(errs))
+	}
+
+	if calendars[0] != nil || errs[0] == nil {
+		t.Errorf("ParseMany()[0] = %v, %v, want an error", calendars[0], errs[0])
+	}
+
+	if errs[1] != nil || len(calendars[1].Events) != 1 {
+		t.Fatalf("ParseMany()[1] = %v, %v, want the calendar despite the failing feed", calendars[1], errs[1])
+	}
+
+	if got := calendars[1].Events[0].StartDate; got.Location() != loc {
+		t.Errorf("ParseMany()[1] StartDate = %v, want it in %v", got, loc)
+	}
+}
+
+func TestParse_status(t *testing.T) {
+	tests := []struct {
+		name      string
+		component string
+		status    string
+		wantErr   bool
+	}{
+		{name: "Confirmed event", component: "VEVENT", status: "CONFIRMED", wantErr: false},
+		{name: "Draft event", component: "VEVENT", status: "DRAFT", wantErr: true},
+		{name: "In-process todo", component: "VTODO", status: "IN-PROCESS", wantErr: false},
+		{name: "Tentative todo", component: "VTODO", status: "TENTATIVE", wantErr: true},
+		{name: "Final journal", component: "VJOURNAL", status: "FINAL", wantErr: false},
+		{name: "Completed journal", component: "VJOURNAL", status: "COMPLETED", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+				"BEGIN:" + tt.component + "\r\nDTSTAMP:19980130T134500Z\r\nUID:uid@example.com\r\n" +
+				"DTSTART:19980415T000000Z\r\nSTATUS:" + tt.status + "\r\nEND:" + tt.component + "\r\nEND:VCALENDAR\r\n"
+
+			c, err := Parse(strings.NewReader(ics), nil)
+			if err != nil {
+				t.Fatalf("Parse() error = %v in lenient mode", err)
+			}
+			if (len(c.Warnings) > 0) != tt.wantErr {
+				t.Errorf("Parse() warnings = %v, wantErr %v", c.Warnings, tt.wantErr)
+			}
+
+			_, err = Parse(strings.NewReader(ics), nil, WithStrict(true))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParse_calscale(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\nCALSCALE:JULIAN\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:19980130T134500Z\r\nUID:uid@example.com\r\n" +
+		"DTSTART:19980415T000000Z\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.Warnings) != 1 {
+		t.Errorf("got %d warnings, want 1", len(c.Warnings))
+	}
+
+	if _, err := Parse(strings.NewReader(ics), nil, WithStrict(true)); err == nil {
+		t.Error("expected an error on unknown CALSCALE in strict mode")
+	}
+}
+
+func TestParse_calendarValidatedOnce(t *testing.T) {
+	event := "BEGIN:VEVENT\r\nDTSTAMP:19980130T134500Z\r\nUID:%d@example.com\r\nDTSTART:19980415T000000Z\r\nEND:VEVENT\r\n"
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\nCALSCALE:JULIAN\r\n" +
+		fmt.Sprintf(event, 1) + fmt.Sprintf(event, 2) + "BEGIN:VTODO\r\nDTSTAMP:19980130T134500Z\r\nUID:3@example.com\r\nEND:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.Warnings) != 1 {
+		t.Errorf("got warnings %v, want a single one", c.Warnings)
+	}
+
+	if _, err := Parse(strings.NewReader(strings.Replace(ics, "PRODID:test\r\n", "", 1)), nil); err == nil {
+		t.Error("expected an error on missing PRODID")
+	}
+}
+
+func TestParse_missingFinalCRLF(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:19980130T134500Z\r\nUID:uid@example.com\r\n" +
+		"DTSTART:19980415T000000Z\r\nEND:VEVENT\r\nEND:VCALENDAR"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.Events) != 1 {
+		t.Errorf("got %d events, want 1", len(c.Events))
+	}
+}
+
+func TestParse_concurrent(t *testing.T) {
+	inputs := make([][]byte, 0, len(calendarList))
+	for _, filename := range calendarList {
+		ics, _ := os.ReadFile(filename)
+		inputs = append(inputs, ics)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8*len(inputs))
+
+	for i := 0; i < 8; i++ {
+		for j, ics := range inputs {
+			wg.Add(1)
+			go func(filename string, ics []byte) {
+				defer wg.Done()
+				if _, err := Parse(bytes.NewReader(ics), nil); err != nil {
+					errs <- fmt.Errorf("%v on '%s'", err, filename)
+				}
+			}(calendarList[j], ics)
+		}
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestParse_noGoroutineLeak(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 10; i++ {
+		// parsing stops on the first error, leaving unread items in the lexer
+		_, _ = Parse(strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:1\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"), nil)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("got %d goroutines after parsing, want %d", after, before)
+	}
+}
+
+func TestParse_repeatedValueParam(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:19980130T134500Z\r\nUID:uid@example.com\r\n" +
+		"DTSTART;VALUE=DATE-TIME;VALUE=DATE:19980415\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := time.Date(1998, time.April, 15, 0, 0, 0, 0, time.UTC)
+
+	if got := c.Events[0].StartDate; !got.Equal(want) {
+		t.Errorf("StartDate = %v, want %v", got, want)
+	}
+}
+
+func TestParse_repeatedParam(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:19980130T134500Z\r\nUID:uid@example.com\r\nDTSTART:19980415T000000Z\r\n" +
+		"X-APPLE-STRUCTURED-LOCATION;VALUE=URI;X-TITLE=Office;X-ALIAS=HQ;X-ALIAS=Main,Head office:geo:48.85,2.35\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prop := findProperty("X-APPLE-STRUCTURED-LOCATION", c.Events[0].Properties)
+	want := []string{"HQ", "Main", "Head office"}
+
+	if got := prop.ParamValues("X-ALIAS"); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("ParamValues(X-ALIAS) = %q, want %q", got, want)
+	}
+
+	if got := prop.ParamValues("X-MISSING"); len(got) != 0 {
+		t.Errorf("ParamValues(X-MISSING) = %q, want none", got)
+	}
+
+	tests := []struct {
+		name string
+		line string
+	}{
+		{name: "Repeated", line: "X-PROP;X-TITLE=a;X-TITLE=b:value"},
+		{name: "Repeated list", line: "X-PROP;X-ALIAS=HQ;X-ALIAS=Main,Head office:value"},
+		{name: "List", line: "ATTENDEE;MEMBER=\"mailto:a@example.com\",\"mailto:b@example.com\":mailto:c@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" + tt.line + "\r\nEND:VCALENDAR\r\n"
+
+			c, err := Parse(strings.NewReader(ics), time.UTC)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := formatProperty(c.Properties[len(c.Properties)-1]); got != tt.line {
+				t.Errorf("formatProperty() = %q, want the occurrences kept as %q", got, tt.line)
+			}
+		})
+	}
+}
+
+func TestParse_unknownMethod(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\nMETHOD:INVITE\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:19980130T134500Z\r\nUID:uid@example.com\r\n" +
+		"DTSTART:19980415T000000Z\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	if _, err := Parse(strings.NewReader(ics), nil, WithStrict(true)); err == nil {
+		t.Error("expected an error on unknown METHOD in strict mode")
+	}
+}
+
+func TestParse_multiValueDate(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:19980130T134500Z\r\nUID:uid@example.com\r\n" +
+		"DTSTART;VALUE=DATE:19980415,19980416\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.Warnings) != 1 {
+		t.Errorf("got %d warnings, want 1", len(c.Warnings))
+	}
+
+	v := c.Events[0]
+	if want := time.Date(1998, time.April, 15, 0, 0, 0, 0, time.UTC); !v.StartDate.Equal(want) || !v.AllDay {
+		t.Errorf("StartDate = %v, AllDay = %v, want %v all day", v.StartDate, v.AllDay, want)
+	}
+
+	if _, err := Parse(strings.NewReader(ics), nil, WithStrict(true)); err == nil {
+		t.Error("expected an error on multiple DTSTART values in strict mode")
+	}
+}
+
+func Test_basicDate(t *testing.T) {
+	tests := []struct {
+		value        string
+		want         string
+		wantExtended bool
+	}{
+		{value: "20200211T090000Z", want: "20200211T090000Z"},
+		{value: "20200211", want: "20200211"},
+		{value: "-PT15M", want: "-PT15M"},
+		{value: "2020-02-11T09:00:00Z", want: "20200211T090000Z", wantExtended: true},
+		{value: "2020-02-11T09:00:00", want: "20200211T090000", wantExtended: true},
+		{value: "2020-02-11T09:00:00.250+01:00", want: "20200211T090000.250+0100", wantExtended: true},
+		{value: "2020-02-11", want: "20200211", wantExtended: true},
+		{value: "20200211T09:00:00Z", want: "20200211T090000Z", wantExtended: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, extended := basicDate(tt.value)
+			if got != tt.want || extended != tt.wantExtended {
+				t.Errorf("basicDate() = %q, %v, want %q, %v", got, extended, tt.want, tt.wantExtended)
+			}
+		})
+	}
+}
+
+func TestParse_extendedDate(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:2020-02-11T09:00:00Z\r\nUID:uid@example.com\r\n" +
+		"DTSTART;VALUE=DATE:2020-02-11\r\nDTEND;VALUE=DATE:20200212\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := c.Events[0]
+
+	if !v.Timestamp.Equal(time.Date(2020, time.February, 11, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Timestamp = %v, want 2020-02-11 09:00 UTC", v.Timestamp)
+	}
+
+	if !v.AllDay || !v.StartDate.Equal(time.Date(2020, time.February, 11, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("StartDate = %v, AllDay = %v, want the all-day 2020-02-11", v.StartDate, v.AllDay)
+	}
+
+	if len(c.Warnings) != 2 {
+		t.Errorf("Warnings = %v, want one per extended date", c.Warnings)
+	}
+
+	if _, err := Parse(strings.NewReader(ics), time.UTC, WithStrict(true)); err == nil {
+		t.Error("Parse() error = nil, want an error in strict mode")
+	}
+}
+
+func TestParse_calendarOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		props   string
+		wantErr bool
+	}{
+		{name: "Canonical", props: "PRODID:test\r\nVERSION:2.0\r\nMETHOD:PUBLISH\r\nX-WR-CALNAME:Test\r\n"},
+		{name: "Partial", props: "VERSION:2.0\r\nPRODID:test\r\n", wantErr: true},
+		{name: "After extra", props: "PRODID:test\r\nX-WR-CALNAME:Test\r\nVERSION:2.0\r\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ics := "BEGIN:VCALENDAR\r\n" + tt.props +
+				"BEGIN:VEVENT\r\nDTSTAMP:19980130T134500Z\r\nUID:uid@example.com\r\n" +
+				"DTSTART:19980415T000000Z\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+			if _, err := Parse(strings.NewReader(ics), nil); err != nil {
+				t.Fatalf("unexpected error in lenient mode: %v", err)
+			}
+
+			_, err := Parse(strings.NewReader(ics), nil, WithStrict(true))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParse_timezone(t *testing.T) {
+	file, _ := os.Open("fixtures/vtimezone.ics")
+	c, err := Parse(file, nil)
+	file.Close()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.Timezones) != 2 {
+		t.Fatalf("got %d timezones, want 2", len(c.Timezones))
+	}
+
+	z := c.Timezones[0]
+
+	if z.TZID != "Custom" || len(z.Standard) != 1 || len(z.Daylight) != 1 {
+		t.Fatalf("Timezone = %+v, want Custom with one STANDARD and one DAYLIGHT", z)
+	}
+
+	if z.Standard[0].Name != "EST" || z.Daylight[0].Name != "EDT" {
+		t.Errorf("rule names = %s and %s, want EST and EDT", z.Standard[0].Name, z.Daylight[0].Name)
+	}
+
+	invalid := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VTIMEZONE\r\nTZID:Custom\r\nBEGIN:STANDARD\r\nDTSTART:19700101T000000\r\nTZOFFSETTO:+0100\r\n" +
+		"END:STANDARD\r\nEND:VTIMEZONE\r\nEND:VCALENDAR\r\n"
+
+	if _, err := Parse(strings.NewReader(invalid), nil); err == nil {
+		t.Error("expected an error on missing TZOFFSETFROM")
+	}
+}
+
+func Test_unescapeParamValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "George Herman Ruth", want: "George Herman Ruth"},
+		{value: "George Herman ^'Babe^' Ruth", want: "George Herman \"Babe\" Ruth"},
+		{value: "Pittsburgh Pirates^n118 Bellerive Ave.", want: "Pittsburgh Pirates\n118 Bellerive Ave."},
+		{value: "a^^n", want: "a^n"},
+		{value: "a^b^", want: "a^b^"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := unescapeParamValue(tt.value); got != tt.want {
+				t.Errorf("unescapeParamValue() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_caretEscapedParam(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:19980130T134500Z\r\nUID:uid@example.com\r\nDTSTART:19980415T000000Z\r\n" +
+		"ATTENDEE;CN=\"George Herman ^'Babe^' Ruth\":mailto:babe@example.com\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cn, _ := findProperty("ATTENDEE", c.Events[0].Properties).ParamValue("CN")
+
+	if want := "George Herman \"Babe\" Ruth"; cn != want {
+		t.Errorf("CN = %q, want %q", cn, want)
+	}
+}
+
+func Test_readInput(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "ascii", text: "SUMMARY:Team\r\n  meeting\r\n", want: "SUMMARY:Team meeting\r\n"},
+		{name: "between runes", text: "SUMMARY:caf\r\n é\r\n", want: "SUMMARY:café\r\n"},
+		{name: "inside a 2 bytes rune", text: "SUMMARY:caf\xc3\r\n \xa9\r\n", want: "SUMMARY:café\r\n"},
+		{name: "inside a 4 bytes rune", text: "SUMMARY:\xf0\x9f\r\n \x8e\x89 party\r\n", want: "SUMMARY:🎉 party\r\n"},
+		{name: "several folds in a rune", text: "SUMMARY:\xf0\r\n \x9f\r\n \x8e\r\n \x89\r\n", want: "SUMMARY:🎉\r\n"},
+		{name: "bare LF", text: "VERSION:2.0\nSUMMARY:Team\n  meeting\r\n", want: "VERSION:2.0\r\nSUMMARY:Team meeting\r\n"},
+		{name: "bare CR kept", text: "SUMMARY:a\rb\r\r\n", want: "SUMMARY:a\rb\r\r\n"},
+		{name: "missing final CRLF", text: "SUMMARY:Team\r\n  meeting", want: "SUMMARY:Team meeting"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := readInput(strings.NewReader(tt.text))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("readInput() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_foldedMultibyte(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:19980130T134500Z\r\nUID:uid@example.com\r\nDTSTART:19980415T000000Z\r\n" +
+		"SUMMARY:R\xc3\r\n \xa9union d'\xc3\xa9quipe \xe2\x80\r\n \x94 \xe6\x9d\xb1\xe4\xba\r\n \xac\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := c.Events[0].Summary, "Réunion d'équipe — 東京"; got != want {
+		t.Errorf("Summary = %q, want %q", got, want)
+	}
+}
+
+func TestParse_mixedLineEndings(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\nDTSTAMP:19980130T134500Z\r\nUID:uid@example.com\nDTSTART:19980415T000000Z\r\n" +
+		"SUMMARY:Folded\n  with LF\r\n  and CRLF\nEND:VEVENT\r\nEND:VCALENDAR\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := c.Events[0].Summary, "Folded with LF and CRLF"; got != want {
+		t.Errorf("Summary = %q, want %q", got, want)
+	}
+}
+
+func TestParse_duration(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:19980130T134500Z\r\nUID:uid@example.com\r\n" +
+		"DTSTART:19980415T100000Z\r\nDURATION:PT1H30M\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := c.Events[0]
+
+	if v.Duration != 90*time.Minute {
+		t.Errorf("Duration = %v, want %v", v.Duration, 90*time.Minute)
+	}
+
+	if want := time.Date(1998, time.April, 15, 11, 30, 0, 0, time.UTC); !v.EndDate.Equal(want) {
+		t.Errorf("EndDate = %v, want %v", v.EndDate, want)
+	}
+}
+
+func Test_splitText(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{value: "APPOINTMENT", want: []string{"APPOINTMENT"}},
+		{value: "APPOINTMENT,EDUCATION", want: []string{"APPOINTMENT", "EDUCATION"}},
+		{value: `Work\, home,Misc`, want: []string{"Work, home", "Misc"}},
+		{value: `a\\,b`, want: []string{`a\`, "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := splitText(tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_freeBusy(t *testing.T) {
+	file, _ := os.Open("fixtures/freebusy.ics")
+	c, err := Parse(file, nil)
+	file.Close()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.FreeBusys) != 1 {
+		t.Fatalf("got %d freebusy, want 1", len(c.FreeBusys))
+	}
+
+	date := func(day, hour, min int) time.Time {
+		return time.Date(1997, time.October, day, hour, min, 0, 0, time.UTC)
+	}
+
+	want := []*Period{
+		{Start: date(15, 5, 0), End: date(15, 13, 30), Type: FreeBusyBusy},
+		{Start: date(15, 16, 0), End: date(15, 21, 30), Type: FreeBusyBusy},
+		{Start: date(15, 22, 30), End: date(16, 5, 0), Type: FreeBusyFree},
+	}
+
+	got := c.FreeBusys[0].Periods
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d periods, want %d", len(got), len(want))
+	}
+
+	for i := range want {
+		if !got[i].Start.Equal(want[i].Start) || !got[i].End.Equal(want[i].End) || got[i].Type != want[i].Type {
+			t.Errorf("Periods[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParse_relaxedDTStamp(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nUID:uid@example.com\r\nDTSTART:19980415T100000Z\r\nEND:VEVENT\r\n" +
+		"BEGIN:VTODO\r\nUID:todo@example.com\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+
+	if _, err := Parse(strings.NewReader(ics), nil); err == nil {
+		t.Error("expected an error on missing DTSTAMP")
+	}
+
+	c, err := Parse(strings.NewReader(ics), nil, WithRelaxedDTStamp(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := time.Date(1998, time.April, 15, 10, 0, 0, 0, time.UTC); !c.Events[0].Timestamp.Equal(want) {
+		t.Errorf("Timestamp = %v, want %v", c.Events[0].Timestamp, want)
+	}
+
+	if c.Todos[0].Timestamp.IsZero() {
+		t.Error("Timestamp should default to now without DTSTART")
+	}
+}
+
+func TestParse_errorContext(t *testing.T) {
+	tests := []struct {
+		name string
+		ics  string
+		want string
+	}{
+		{
+			name: "Event",
+			ics:  "BEGIN:VEVENT\r\nUID:abc@example.com\r\nDTSTART:20200211T100000Z\r\nEND:VEVENT\r\n",
+			want: "error in VEVENT (UID=abc@example.com): missing required property \"dtstamp\"",
+		},
+		{
+			name: "Alarm",
+			ics: "BEGIN:VEVENT\r\nUID:abc@example.com\r\nDTSTAMP:20200211T090000Z\r\nDTSTART:20200211T100000Z\r\n" +
+				"BEGIN:VALARM\r\nACTION:DISPLAY\r\nEND:VEVENT\r\n",
+			want: "error in VALARM (UID=abc@example.com): line 10: found <END:VEVENT>, expected END:VALARM",
+		},
+		{
+			name: "Unknown UID",
+			ics:  "BEGIN:VTODO\r\nDTSTAMP:20200211T090000Z\r\nEND:VTODO\r\n",
+			want: "error in VTODO: missing required property \"uid\"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" + tt.ics + "END:VCALENDAR\r\n"
+
+			_, err := Parse(strings.NewReader(ics), nil)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Parse() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseDate_offSpec(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "20200211T090000.000Z", want: time.Date(2020, time.February, 11, 9, 0, 0, 0, time.UTC)},
+		{value: "20200211T090000.250Z", want: time.Date(2020, time.February, 11, 9, 0, 0, 250000000, time.UTC)},
+		{value: "20200211T090000.5", want: time.Date(2020, time.February, 11, 9, 0, 0, 500000000, time.UTC)},
+		{value: "20200211T090000+0100", want: time.Date(2020, time.February, 11, 8, 0, 0, 0, time.UTC)},
+		{value: "20200211T090000-0530", want: time.Date(2020, time.February, 11, 14, 30, 0, 0, time.UTC)},
+		{value: "20200211T090000+010030", want: time.Date(2020, time.February, 11, 7, 59, 30, 0, time.UTC)},
+		{value: "20200211T090000.125+0100", want: time.Date(2020, time.February, 11, 8, 0, 0, 125000000, time.UTC)},
+		{value: "20200211T090000+01", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseDate(&Property{Name: "DTSTART", Value: tt.value}, time.UTC, true)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseDate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !got.Equal(tt.want) {
+				t.Errorf("parseDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseUTCTime(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	tests := []struct {
+		prop *Property
+		want time.Time
+	}{
+		{prop: &Property{Name: "DTSTAMP", Value: "20200211T090000Z"}, want: time.Date(2020, time.February, 11, 9, 0, 0, 0, time.UTC)},
+		{prop: &Property{Name: "COMPLETED", Value: "20200211T090000.250Z"}, want: time.Date(2020, time.February, 11, 9, 0, 0, 250000000, time.UTC)},
+		{prop: &Property{Name: "LAST-MODIFIED", Value: "20200211T090000+0100"}, want: time.Date(2020, time.February, 11, 8, 0, 0, 0, time.UTC)},
+		{prop: &Property{Name: "CREATED", Value: "20200211T090000"}, want: time.Date(2020, time.February, 11, 9, 0, 0, 0, time.UTC)},
+		{prop: &Property{Name: "DTSTAMP", Params: map[string]*Param{"TZID": {Values: []string{"Europe/Paris"}}}, Value: "20200211T090000"}, want: time.Date(2020, time.February, 11, 9, 0, 0, 0, paris)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prop.Value, func(t *testing.T) {
+			got, err := parseUTCTime(tt.prop, true)
+			if err != nil {
+				t.Fatalf("parseUTCTime() error = %v", err)
+			}
+			if !got.Equal(tt.want) || got.Location() != time.UTC {
+				t.Errorf("parseUTCTime() = %v, want %v in UTC", got, tt.want)
+			}
+			if got := formatUTCTime(got); got != tt.want.UTC().Format(dateTimeLayoutUTC) {
+				t.Errorf("formatUTCTime() = %s, want %s", got, tt.want.UTC().Format(dateTimeLayoutUTC))
+			}
+		})
+	}
+}
+
+func TestParse_nesting(t *testing.T) {
+	event := "BEGIN:VEVENT\r\nUID:abc@example.com\r\nDTSTAMP:20200211T090000Z\r\nDTSTART:20200211T100000Z\r\n"
+
+	tests := []struct {
+		name string
+		ics  string
+		want string
+	}{
+		{
+			name: "Event inside an event",
+			ics:  event + event + "END:VEVENT\r\nEND:VEVENT\r\n",
+			want: "line 8: found <BEGIN:VEVENT> inside VEVENT, VEVENT is not allowed there",
+		},
+		{
+			name: "END without BEGIN",
+			ics:  "END:VTODO\r\n",
+			want: "line 4: found <END:VTODO> without a matching BEGIN",
+		},
+		{
+			name: "Mismatched END",
+			ics:  event + "END:VTODO\r\n",
+			want: "line 8: found <END:VTODO>, expected END:VEVENT",
+		},
+		{
+			name: "Alarm inside a journal",
+			ics:  "BEGIN:VJOURNAL\r\nBEGIN:VALARM\r\nEND:VALARM\r\nEND:VJOURNAL\r\n",
+			want: "line 5: found <BEGIN:VALARM> inside VJOURNAL, VALARM is not allowed there",
+		},
+		{
+			name: "Unclosed event",
+			ics:  event,
+			want: "line 8: found <END:VCALENDAR>, expected END:VEVENT",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" + tt.ics + "END:VCALENDAR\r\n"
+
+			_, err := Parse(strings.NewReader(ics), nil)
+			if err == nil || !strings.HasSuffix(err.Error(), tt.want) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_untilValueType(t *testing.T) {
+	tests := []struct {
+		name    string
+		dtstart string
+		until   string
+		wantErr bool
+	}{
+		{name: "dates", dtstart: "DTSTART;VALUE=DATE:19980415", until: "19980420", wantErr: false},
+		{name: "utc date-times", dtstart: "DTSTART:19980415T090000Z", until: "19980420T090000Z", wantErr: false},
+		{name: "floating date-times", dtstart: "DTSTART:19980415T090000", until: "19980420T090000", wantErr: false},
+		{name: "date-time until", dtstart: "DTSTART;VALUE=DATE:19980415", until: "19980420T000000Z", wantErr: true},
+		{name: "date until", dtstart: "DTSTART:19980415T090000Z", until: "19980420", wantErr: true},
+		{name: "floating until", dtstart: "DTSTART;TZID=Europe/Paris:19980415T090000", until: "19980420T090000", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+				"BEGIN:VEVENT\r\nDTSTAMP:19980130T134500Z\r\nUID:uid@example.com\r\n" +
+				tt.dtstart + "\r\nRRULE:FREQ=DAILY;UNTIL=" + tt.until + "\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+			c, err := Parse(strings.NewReader(ics), time.UTC)
+			if err != nil {
+				t.Fatalf("Parse() error = %v in lenient mode", err)
+			}
+			if (len(c.Warnings) > 0) != tt.wantErr {
+				t.Errorf("Parse() warnings = %v, wantErr %v", c.Warnings, tt.wantErr)
+			}
+
+			_, err = Parse(strings.NewReader(ics), time.UTC, WithStrict(true))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCountComponents(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nUID:1@example.com\r\nBEGIN:VALARM\r\nACTION:DISPLAY\r\nEND:VALARM\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:2@example.com\r\nEND:VEVENT\r\n" +
+		"BEGIN:VTODO\r\nUID:3@example.com\r\nEND:VTODO\r\n" +
+		"BEGIN:X-VENDOR\r\nX-FOO:bar\r\nEND:X-VENDOR\r\n" +
+		"END:VCALENDAR\r\n"
+
+	got, err := CountComponents(strings.NewReader(ics))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int{"VCALENDAR": 1, "VEVENT": 2, "VALARM": 1, "VTODO": 1, "X-VENDOR": 1}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CountComponents() = %v, want %v", got, want)
+	}
+
+	if _, err := CountComponents(strings.NewReader("BEGIN:VCALENDAR\nPRODID test\r\n")); err == nil {
+		t.Error("CountComponents() expected an error on a malformed content line")
+	}
+}
+
+func TestParse_recurrenceScope(t *testing.T) {
+	tests := []struct {
+		name     string
+		calendar string
+		event    string
+		wantErr  bool
+	}{
+		{name: "rrule in event", event: "RRULE:FREQ=DAILY\r\n", wantErr: false},
+		{name: "exdate in event", event: "EXDATE:19980416T000000Z\r\n", wantErr: false},
+		{name: "rrule in alarm", event: "BEGIN:VALARM\r\nACTION:DISPLAY\r\nTRIGGER:-PT15M\r\nDESCRIPTION:Reminder\r\nRRULE:FREQ=DAILY\r\nEND:VALARM\r\n", wantErr: true},
+		{name: "rdate in calendar", calendar: "RDATE:19980416T000000Z\r\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" + tt.calendar +
+				"BEGIN:VEVENT\r\nDTSTAMP:19980130T134500Z\r\nUID:uid@example.com\r\n" +
+				"DTSTART:19980415T000000Z\r\n" + tt.event + "END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+			c, err := Parse(strings.NewReader(ics), nil)
+			if err != nil {
+				t.Fatalf("Parse() error = %v in lenient mode", err)
+			}
+			if (len(c.Warnings) > 0) != tt.wantErr {
+				t.Errorf("Parse() warnings = %v, wantErr %v", c.Warnings, tt.wantErr)
+			}
+
+			_, err = Parse(strings.NewReader(ics), nil, WithStrict(true))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParse_methodAfterEvents(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nUID:uid@example.com\r\nDTSTART:19980415T000000Z\r\nEND:VEVENT\r\n" +
+		"METHOD:%s\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(fmt.Sprintf(ics, MethodRequest)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Method != MethodRequest || len(c.Events) != 1 {
+		t.Errorf("Method = %q with %d events, want %q with 1", c.Method, len(c.Events), MethodRequest)
+	}
+
+	// without METHOD the missing DTSTAMP is reported at the end
+	_, err = Parse(strings.NewReader(strings.Replace(ics, "METHOD:%s\r\n", "", 1)), nil)
+	if err == nil || !strings.Contains(err.Error(), "UID=uid@example.com") {
+		t.Errorf("Parse() error = %v, want the missing DTSTAMP of the event", err)
+	}
+}
+
+func TestParse_missingDTStart(t *testing.T) {
+	tests := []struct {
+		method  string
+		wantErr bool
+	}{
+		{method: MethodPublish, wantErr: true},
+		{method: MethodRequest, wantErr: true},
+		{method: MethodReply, wantErr: false},
+		{method: MethodCancel, wantErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\nMETHOD:" + tt.method + "\r\n" +
+				"BEGIN:VEVENT\r\nDTSTAMP:19980130T134500Z\r\nUID:uid@example.com\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+			c, err := Parse(strings.NewReader(ics), nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !c.Events[0].EndDate.IsZero() {
+				t.Errorf("EndDate = %v, want none without DTSTART", c.Events[0].EndDate)
+			}
+		})
+	}
+}
+
+func TestParseEvents(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\nX-WR-CALNAME:Team\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:19980130T134500Z\r\nUID:1@example.com\r\nDTSTART:19980415T000000Z\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:19980130T134500Z\r\nUID:2@example.com\r\nDTSTART:19980416T000000Z\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:19980130T134500Z\r\nUID:3@example.com\r\nDTSTART:19980417T000000\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	var name string
+	var last *Event
+	uids := make([]string, 0)
+	loc := time.FixedZone("UTC+2", 2*60*60)
+
+	err := ParseEvents(strings.NewReader(ics), loc, func(v *Event) error {
+		uids = append(uids, v.UID)
+		last = v
+		return nil
+	}, WithPreamble(func(c *Calendar) error {
+		name = c.Name
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"1@example.com", "2@example.com", "3@example.com"}; !reflect.DeepEqual(uids, want) {
+		t.Errorf("ParseEvents() events = %v, want %v", uids, want)
+	}
+
+	if name != "Team" {
+		t.Errorf("preamble Name = %q, want %q", name, "Team")
+	}
+
+	if want := time.Date(1998, time.April, 17, 0, 0, 0, 0, loc); !last.StartDate.Equal(want) || last.StartDate.Location() != loc {
+		t.Errorf("ParseEvents() floating StartDate = %v, want %v", last.StartDate, want)
+	}
+
+	errStop := errors.New("stop")
+	count := 0
+
+	err = ParseEvents(strings.NewReader(ics), nil, func(v *Event) error {
+		count++
+		return errStop
+	})
+
+	if err != errStop || count != 1 {
+		t.Errorf("ParseEvents() error = %v after %d events, want %v after 1", err, count, errStop)
+	}
+}
+
+func TestParse_noEvents(t *testing.T) {
+	timezone := "BEGIN:VTIMEZONE\r\nTZID:Europe/Paris\r\nBEGIN:STANDARD\r\nDTSTART:19701025T030000\r\n" +
+		"TZOFFSETFROM:+0200\r\nTZOFFSETTO:+0100\r\nEND:STANDARD\r\nEND:VTIMEZONE\r\n"
+
+	tests := []struct {
+		name    string
+		ics     string
+		wantErr bool
+	}{
+		{name: "properties only", ics: "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\nX-WR-CALNAME:Empty\r\nEND:VCALENDAR\r\n"},
+		{name: "timezone only", ics: "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" + timezone + "END:VCALENDAR\r\n"},
+		{name: "missing prodid", ics: "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nEND:VCALENDAR\r\n", wantErr: true},
+		{name: "missing version with timezone", ics: "BEGIN:VCALENDAR\r\nPRODID:test\r\n" + timezone + "END:VCALENDAR\r\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse(strings.NewReader(tt.ics), nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(c.Events) != 0 || c.Prodid != "test" {
+				t.Errorf("Parse() = %+v, want a calendar without events", c)
+			}
+
+			var buf bytes.Buffer
+			if err := Format(&buf, c); err != nil {
+				t.Fatal(err)
+			}
+
+			got := buf.String()
+
+			if !strings.HasPrefix(got, "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n") || !strings.HasSuffix(got, "\r\nEND:VCALENDAR\r\n") {
+				t.Errorf("Format() = %q, want a well-formed calendar", got)
+			}
+
+			if _, err := Parse(strings.NewReader(got), nil, WithStrict(true)); err != nil {
+				t.Errorf("Parse() error = %v on the formatted calendar", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package ical
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParamValue returns the first value of the param with the given name. It
+// is safe to use on a param without any value, which is reported as missing.
+func (prop *Property) ParamValue(name string) (string, bool) {
+	param, ok := prop.Params[name]
+
+	if !ok || param == nil || len(param.Values) == 0 {
+		return "", false
+	}
+
+	return param.Values[0], true
+}
+
+// ParamValues returns a copy of the values of the param with the given
+// name, in document order when the param is repeated, or an empty list
+// when the property doesn't have it
+func (prop *Property) ParamValues(name string) []string {
+	values := make([]string, 0)
+
+	if param, ok := prop.Params[name]; ok && param != nil {
+		values = append(values, param.Values...)
+	}
+
+	return values
+}
+
+// MediaType returns the media type of the property value, as given by the
+// FMTTYPE param. The media type syntax is not validated.
+func (prop *Property) MediaType() (string, bool) {
+	return prop.ParamValue("FMTTYPE")
+}
+
+// ValueType returns the type of the property value, given by its VALUE
+// param or else registered for its name. The package reads VALUE through it
+// only, so a repeated VALUE is read the same everywhere.
+func (prop *Property) ValueType() ValueType {
+	// A malformed property may repeat VALUE, the last one wins
+	if val, ok := prop.Params["VALUE"]; ok && val != nil && len(val.Values) > 0 {
+		return ValueType(strings.ToUpper(val.Values[len(val.Values)-1]))
+	}
+	return defaultValueType(prop.Name)
+}
+
+// Text returns the property value, unescaped when its type is TEXT
+func (prop *Property) Text() string {
+	if prop.ValueType() == ValueText {
+		return unescapeText(prop.Value)
+	}
+	return prop.Value
+}
+
+// SetText sets the property value, escaping it when its type is TEXT
+func (prop *Property) SetText(value string) {
+	if prop.ValueType() == ValueText {
+		value = escapeText(value)
+	}
+	prop.Value = value
+}
+
+// Int returns the value of an INTEGER property
+//
+// integer = (["+"] / "-") 1*DIGIT
+func (prop *Property) Int() (int, error) {
+	i, err := strconv.Atoi(prop.Value)
+
+	if err != nil {
+		return 0, fmt.Errorf("invalid integer %q in %s", prop.Value, prop.Name)
+	}
+
+	return i, nil
+}
+
+// propertyMap indexes the properties by name, keeping the document order
+// of the repeated ones
+func propertyMap(properties []*Property) map[string][]*Property {
+	m := make(map[string][]*Property, len(properties))
+
+	for _, prop := range properties {
+		m[prop.Name] = append(m[prop.Name], prop)
+	}
+
+	return m
+}
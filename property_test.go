@@ -0,0 +1,104 @@
+package ical
+
+import (
+	"testing"
+)
+
+func TestProperty_MediaType(t *testing.T) {
+	prop := NewProperty()
+	prop.Name = "ATTACH"
+
+	if _, ok := prop.MediaType(); ok {
+		t.Error("MediaType() should not be found without FMTTYPE")
+	}
+
+	prop.Params["FMTTYPE"] = &Param{Values: []string{"application/msword"}}
+
+	if got, ok := prop.MediaType(); !ok || got != "application/msword" {
+		t.Errorf("MediaType() = %q, %v, want %q, true", got, ok, "application/msword")
+	}
+}
+
+func TestProperty_ParamValue(t *testing.T) {
+	prop := &Property{
+		Name: "DTSTART",
+		Params: map[string]*Param{
+			"TZID":  {Values: []string{"Europe/Paris"}},
+			"VALUE": {Values: []string{}},
+			"X-NIL": nil,
+		},
+	}
+
+	if got, ok := prop.ParamValue("TZID"); !ok || got != "Europe/Paris" {
+		t.Errorf("ParamValue(TZID) = %q, %v, want %q, true", got, ok, "Europe/Paris")
+	}
+
+	for _, name := range []string{"VALUE", "X-NIL", "MISSING"} {
+		if got, ok := prop.ParamValue(name); ok {
+			t.Errorf("ParamValue(%s) = %q, want it missing", name, got)
+		}
+	}
+
+	if got, ok := (&Property{Name: "UID"}).ParamValue("TZID"); ok {
+		t.Errorf("ParamValue(TZID) = %q on a property without params, want it missing", got)
+	}
+}
+
+func TestProperty_Text(t *testing.T) {
+	RegisterProperty("X-TEST-URI", ValueURI)
+
+	tests := []struct {
+		name   string
+		params map[string]*Param
+		value  string
+		want   string
+	}{
+		{name: "SUMMARY", value: "Meeting\\, room 1\\nBuilding A", want: "Meeting, room 1\nBuilding A"},
+		{name: "X-CUSTOM", value: "a\\;b", want: "a;b"},
+		{name: "URL", value: "http://example.com/a,b", want: "http://example.com/a,b"},
+		{name: "x-test-uri", value: "http://example.com/a\\,b", want: "http://example.com/a\\,b"},
+		{name: "ATTACH", params: map[string]*Param{"VALUE": {Values: []string{"TEXT"}}}, value: "see\\, the notes", want: "see, the notes"},
+		{name: "DESCRIPTION", params: map[string]*Param{"VALUE": {Values: []string{"uri"}}}, value: "http://example.com/a,b", want: "http://example.com/a,b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prop := &Property{Name: tt.name, Params: tt.params, Value: tt.value}
+			if got := prop.Text(); got != tt.want {
+				t.Errorf("Text() = %q, want %q", got, tt.want)
+			}
+
+			prop.SetText(tt.want)
+			if prop.Value != tt.value {
+				t.Errorf("SetText() set %q, want %q", prop.Value, tt.value)
+			}
+		})
+	}
+}
+
+func TestProperty_Int(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "1", want: 1},
+		{value: "+2", want: 2},
+		{value: "-10", want: -10},
+		{value: "", wantErr: true},
+		{value: "1.5", wantErr: true},
+		{value: "high", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			prop := &Property{Name: "PRIORITY", Value: tt.value}
+			got, err := prop.Int()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Int() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Int() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
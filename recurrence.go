@@ -0,0 +1,814 @@
+package ical
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrTooManyInstances is returned when the expansion of a recurring event
+// produces more instances than allowed
+var ErrTooManyInstances = errors.New("too many instances")
+
+// maxRecurrencePeriods is the hard limit of periods (years, months, weeks,
+// days...) a recurrence iterator walks through, it protects against the
+// rules which never or rarely produce an instance
+const maxRecurrencePeriods = 1000000
+
+// A Frequency is the type of a recurrence rule
+type Frequency string
+
+// Frequencies of a recurrence rule
+const (
+	FrequencySecondly Frequency = "SECONDLY"
+	FrequencyMinutely Frequency = "MINUTELY"
+	FrequencyHourly   Frequency = "HOURLY"
+	FrequencyDaily    Frequency = "DAILY"
+	FrequencyWeekly   Frequency = "WEEKLY"
+	FrequencyMonthly  Frequency = "MONTHLY"
+	FrequencyYearly   Frequency = "YEARLY"
+)
+
+// A Recurrence represent a RRULE property, the rule describing the
+// repetition of an event
+type Recurrence struct {
+	Frequency  Frequency
+	Until      time.Time // last instant an instance may start at, inclusive
+	Count      int
+	Interval   int
+	BySecond   []int
+	ByMinute   []int
+	ByHour     []int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []int
+	BySetPos   []int
+	WeekStart  time.Weekday
+	untilDate  bool // UNTIL is a DATE
+}
+
+// A WeekdayNum is a day of the week, optionally with its position in the
+// month or the year, for example the last Friday is {-1, time.Friday}
+type WeekdayNum struct {
+	Ordinal int // 0 for every occurrence of the weekday
+	Weekday time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// ParseRecurrence parses the value of a RRULE property, a floating UNTIL
+// is interpreted in the location loc. BYYEARDAY and BYWEEKNO are not
+// supported.
+//
+// recur = recur-rule-part *( ";" recur-rule-part )
+func ParseRecurrence(value string, loc *time.Location) (*Recurrence, error) {
+	r := &Recurrence{Interval: 1, WeekStart: time.Monday}
+
+	for _, part := range strings.Split(value, ";") {
+		name, val, found := strings.Cut(part, "=")
+
+		if !found {
+			return nil, fmt.Errorf("invalid recurrence rule part %q", part)
+		}
+
+		var err error
+
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			r.Frequency = Frequency(strings.ToUpper(val))
+
+			switch r.Frequency {
+			case FrequencySecondly, FrequencyMinutely, FrequencyHourly, FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly:
+			default:
+				err = fmt.Errorf("unknown frequency %s", val)
+			}
+		case "UNTIL":
+			r.Until, err = parseDate(&Property{Value: val}, loc, true)
+
+			// a date includes the whole day
+			if err == nil && len(val) == len(dateLayout) {
+				r.Until = r.Until.AddDate(0, 0, 1).Add(-time.Nanosecond)
+				r.untilDate = true
+			}
+		case "COUNT":
+			r.Count, err = parseRecurrenceInt(val, 1, -1)
+		case "INTERVAL":
+			r.Interval, err = parseRecurrenceInt(val, 1, -1)
+		case "BYSECOND":
+			r.BySecond, err = parseRecurrenceInts(val, 0, 60, false)
+		case "BYMINUTE":
+			r.ByMinute, err = parseRecurrenceInts(val, 0, 59, false)
+		case "BYHOUR":
+			r.ByHour, err = parseRecurrenceInts(val, 0, 23, false)
+		case "BYDAY":
+			r.ByDay, err = parseWeekdayNums(val)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseRecurrenceInts(val, 1, 31, true)
+		case "BYMONTH":
+			r.ByMonth, err = parseRecurrenceInts(val, 1, 12, false)
+		case "BYSETPOS":
+			r.BySetPos, err = parseRecurrenceInts(val, 1, 366, true)
+		case "WKST":
+			weekday, ok := weekdays[strings.ToUpper(val)]
+
+			if !ok {
+				err = fmt.Errorf("unknown weekday %s", val)
+			}
+
+			r.WeekStart = weekday
+		case "BYYEARDAY", "BYWEEKNO":
+			err = fmt.Errorf("%s is not supported", strings.ToLower(name))
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid recurrence rule %q: %v", value, err)
+		}
+	}
+
+	if r.Frequency == "" {
+		return nil, fmt.Errorf("invalid recurrence rule %q: missing \"freq\"", value)
+	}
+
+	if r.Count > 0 && !r.Until.IsZero() {
+		return nil, fmt.Errorf("invalid recurrence rule %q: either \"until\" or \"count\" MAY appear", value)
+	}
+
+	return r, nil
+}
+
+// checkUntil checks the UNTIL of the rule has the value type of dtstart, as
+// required by RFC 5545: both DATE or both DATE-TIME, and in UTC when dtstart
+// is zoned
+func checkUntil(rule, dtstart *Property) error {
+	if dtstart == nil {
+		return nil
+	}
+
+	for _, part := range strings.Split(rule.Value, ";") {
+		name, until, _ := strings.Cut(part, "=")
+
+		if strings.ToUpper(name) != "UNTIL" {
+			continue
+		}
+
+		_, zoned := dtstart.ParamValue("TZID")
+		zoned = zoned || strings.HasSuffix(dtstart.Value, "Z")
+
+		switch {
+		case isDateProperty(dtstart) && len(until) != len(dateLayout):
+			return fmt.Errorf("\"until\" %s is a DATE-TIME while \"dtstart\" is a DATE", until)
+		case !isDateProperty(dtstart) && len(until) == len(dateLayout):
+			return fmt.Errorf("\"until\" %s is a DATE while \"dtstart\" is a DATE-TIME", until)
+		case !isDateProperty(dtstart) && zoned && !strings.HasSuffix(until, "Z"):
+			return fmt.Errorf("\"until\" %s must be in UTC since \"dtstart\" is zoned", until)
+		}
+	}
+
+	return nil
+}
+
+// alignUntil normalizes an UNTIL whose value type doesn't match the one of
+// the first instance: a DATE-TIME UNTIL of an all-day event includes the
+// whole day it falls on. A DATE UNTIL already covers its whole day and a
+// floating one is read in the location of the first instance.
+func (r *Recurrence) alignUntil(allDay bool, loc *time.Location) {
+	if allDay && !r.untilDate && !r.Until.IsZero() {
+		r.Until = time.Date(r.Until.Year(), r.Until.Month(), r.Until.Day()+1, 0, 0, 0, 0, loc).Add(-time.Nanosecond)
+		r.untilDate = true
+	}
+}
+
+// parseRecurrenceInt parses an integer of at least min, and at most max
+// unless it is negative
+func parseRecurrenceInt(value string, min, max int) (int, error) {
+	n, err := strconv.Atoi(value)
+
+	if err != nil || n < min || (max >= 0 && n > max) {
+		return 0, fmt.Errorf("invalid number %s", value)
+	}
+
+	return n, nil
+}
+
+// parseRecurrenceInts parses a list of integers within min and max,
+// negative ones are accepted when signed is set
+func parseRecurrenceInts(value string, min, max int, signed bool) ([]int, error) {
+	values := make([]int, 0)
+
+	for _, v := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(v)
+		abs := n
+
+		if signed && n < 0 {
+			abs = -n
+		}
+
+		if err != nil || abs < min || abs > max {
+			return nil, fmt.Errorf("invalid number %s", v)
+		}
+
+		values = append(values, n)
+	}
+
+	return values, nil
+}
+
+// parseWeekdayNums parses the list of a BYDAY rule part
+//
+// weekdaynum = [[plus / minus] ordwk] weekday
+func parseWeekdayNums(value string) ([]WeekdayNum, error) {
+	days := make([]WeekdayNum, 0)
+
+	for _, v := range strings.Split(value, ",") {
+		if len(v) < 2 {
+			return nil, fmt.Errorf("invalid weekday %s", v)
+		}
+
+		weekday, ok := weekdays[strings.ToUpper(v[len(v)-2:])]
+
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %s", v)
+		}
+
+		day := WeekdayNum{Weekday: weekday}
+
+		if ordinal := v[:len(v)-2]; ordinal != "" {
+			n, err := strconv.Atoi(ordinal)
+
+			if err != nil || n == 0 || n < -53 || n > 53 {
+				return nil, fmt.Errorf("invalid weekday %s", v)
+			}
+
+			day.Ordinal = n
+		}
+
+		days = append(days, day)
+	}
+
+	return days, nil
+}
+
+// Expand returns the start of the instances of the event overlapping the
+// window from from to to, according to its RRULE, RDATE and EXDATE. The
+// expansion fails with ErrTooManyInstances when there are more than
+// maxInstances instances in the window, a maxInstances of 0 or less
+// disables this limit.
+func (v *Event) Expand(from, to time.Time, maxInstances int) ([]time.Time, error) {
+	loc := v.StartDate.Location()
+	duration := v.EndDate.Sub(v.StartDate)
+	seen := make(map[int64]bool)
+	starts := make([]time.Time, 0)
+
+	// excluded dates are marked as seen so they are never added
+	for _, exdate := range v.recurrenceDates("EXDATE", loc) {
+		seen[exdate.UnixNano()] = true
+	}
+
+	add := func(t time.Time) error {
+		overlaps := t.Before(to) && t.Add(duration).After(from)
+
+		if duration <= 0 {
+			overlaps = !t.Before(from) && t.Before(to)
+		}
+
+		if !overlaps || seen[t.UnixNano()] {
+			return nil
+		}
+
+		if maxInstances > 0 && len(starts) == maxInstances {
+			return ErrTooManyInstances
+		}
+
+		seen[t.UnixNano()] = true
+		starts = append(starts, t)
+		return nil
+	}
+
+	rule := findProperty("RRULE", v.Properties)
+	excluded := v.exclusionRules(to)
+
+	if rule == nil {
+		if !excluded(v.StartDate) {
+			if err := add(v.StartDate); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		r, err := ParseRecurrence(rule.Value, loc)
+
+		if err != nil {
+			return nil, err
+		}
+
+		r.alignUntil(v.AllDay, loc)
+
+		it := r.iterator(v.StartDate, to)
+
+		for t, ok := it.next(); ok; t, ok = it.next() {
+			if excluded(t) {
+				continue
+			}
+
+			if err := add(t); err != nil {
+				return nil, err
+			}
+		}
+
+		if it.exhausted {
+			return nil, ErrTooManyInstances
+		}
+	}
+
+	excluded = v.exclusionRules(to)
+
+	for _, rdate := range v.sortedRecurrenceDates("RDATE", loc) {
+		if excluded(rdate) {
+			continue
+		}
+
+		if err := add(rdate); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(starts, func(i, j int) bool {
+		return starts[i].Before(starts[j])
+	})
+
+	return starts, nil
+}
+
+// Instances returns the first n instances of the event from its DTSTART,
+// according to its RRULE, RDATE and EXDATE, as copies of the event moved to
+// the start of each instance. An event without RRULE is its single
+// instance, as is an event whose RRULE is invalid.
+func (v *Event) Instances(n int) []*Event {
+	if n <= 0 {
+		return []*Event{}
+	}
+
+	loc := v.StartDate.Location()
+	seen := make(map[int64]bool)
+	starts := make([]time.Time, 0, n)
+
+	// excluded dates are marked as seen so they are never added
+	for _, exdate := range v.recurrenceDates("EXDATE", loc) {
+		seen[exdate.UnixNano()] = true
+	}
+
+	add := func(t time.Time) {
+		if !seen[t.UnixNano()] {
+			seen[t.UnixNano()] = true
+			starts = append(starts, t)
+		}
+	}
+
+	horizon := time.Date(9999, time.December, 31, 0, 0, 0, 0, loc)
+	rule := findProperty("RRULE", v.Properties)
+	excluded := v.exclusionRules(horizon)
+
+	if rule == nil {
+		if !excluded(v.StartDate) {
+			add(v.StartDate)
+		}
+	} else {
+		r, err := ParseRecurrence(rule.Value, loc)
+
+		if err != nil {
+			return []*Event{v.Clone()}
+		}
+
+		r.alignUntil(v.AllDay, loc)
+
+		it := r.iterator(v.StartDate, horizon)
+
+		for t, ok := it.next(); ok && len(starts) < n; t, ok = it.next() {
+			if !excluded(t) {
+				add(t)
+			}
+		}
+	}
+
+	excluded = v.exclusionRules(horizon)
+
+	// the first n instances are among the rule ones and the added dates
+	for _, rdate := range v.sortedRecurrenceDates("RDATE", loc) {
+		if !excluded(rdate) {
+			add(rdate)
+		}
+	}
+
+	sort.Slice(starts, func(i, j int) bool {
+		return starts[i].Before(starts[j])
+	})
+
+	if len(starts) > n {
+		starts = starts[:n]
+	}
+
+	duration := v.EndDate.Sub(v.StartDate)
+	instances := make([]*Event, 0, len(starts))
+
+	for _, start := range starts {
+		instance := v.Clone()
+		instance.StartDate = start
+		instance.EndDate = start.Add(duration)
+		instances = append(instances, instance)
+	}
+
+	return instances
+}
+
+// recurrenceDates parses the dates of the RDATE or EXDATE properties, the
+// start of a PERIOD value is kept. The params of a property, its TZID in
+// particular, apply to each date of its list.
+func (v *Event) recurrenceDates(name string, loc *time.Location) []time.Time {
+	dates := make([]time.Time, 0)
+
+	for _, prop := range findProperties(name, v.Properties) {
+		for _, value := range strings.Split(prop.Value, ",") {
+			value, _, _ = strings.Cut(value, "/")
+
+			if date, err := parseDate(&Property{Name: name, Params: prop.Params, Value: value}, loc, true); err == nil {
+				dates = append(dates, date)
+			}
+		}
+	}
+
+	return dates
+}
+
+// sortedRecurrenceDates returns the dates of recurrenceDates in
+// chronological order
+func (v *Event) sortedRecurrenceDates(name string, loc *time.Location) []time.Time {
+	dates := v.recurrenceDates(name, loc)
+
+	sort.Slice(dates, func(i, j int) bool {
+		return dates[i].Before(dates[j])
+	})
+
+	return dates
+}
+
+// exclusionRules returns a function checking if an instance is one of the
+// instances of the EXRULEs of the event, up to horizon. Unlike a RRULE, an
+// EXRULE only excludes the DTSTART when it matches the rule. The function
+// walks through the rules along the instances, it must be called in
+// chronological order.
+func (v *Event) exclusionRules(horizon time.Time) func(t time.Time) bool {
+	type walker struct {
+		it   *recurrenceIterator
+		next time.Time
+		ok   bool
+	}
+
+	walkers := make([]*walker, 0, len(v.ExRules))
+
+	for _, r := range v.ExRules {
+		rr := *r
+		rr.alignUntil(v.AllDay, v.StartDate.Location())
+
+		it := rr.iterator(v.StartDate, horizon)
+		it.pending = nil
+		it.matchStart = true
+
+		w := &walker{it: it}
+		w.next, w.ok = it.next()
+		walkers = append(walkers, w)
+	}
+
+	return func(t time.Time) bool {
+		for _, w := range walkers {
+			for w.ok && w.next.Before(t) {
+				w.next, w.ok = w.it.next()
+			}
+
+			if w.ok && w.next.Equal(t) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// recurrenceIterator walks through the instances of a recurrence rule, in
+// chronological order, period after period
+type recurrenceIterator struct {
+	r          *Recurrence
+	start      time.Time
+	horizon    time.Time // no instance is needed after the horizon
+	period     int       // index of the next period
+	pending    []time.Time
+	count      int
+	done       bool
+	exhausted  bool // the hard limit of periods was reached
+	matchStart bool // the start is an instance only when it matches the rule
+}
+
+// iterator creates an iterator of the rule instances from start, which is
+// the first instance, up to horizon
+func (r *Recurrence) iterator(start, horizon time.Time) *recurrenceIterator {
+	return &recurrenceIterator{
+		r:       r,
+		start:   start,
+		horizon: horizon,
+		pending: []time.Time{start},
+	}
+}
+
+// next returns the next instance, if any
+func (it *recurrenceIterator) next() (time.Time, bool) {
+	r := it.r
+
+	for len(it.pending) == 0 {
+		if it.done {
+			return time.Time{}, false
+		}
+
+		if it.period == maxRecurrencePeriods {
+			it.exhausted = true
+			return time.Time{}, false
+		}
+
+		start := it.periodStart(it.period)
+		it.period++
+
+		if start.After(it.horizon) || (!r.Until.IsZero() && start.After(r.Until)) {
+			it.done = true
+			return time.Time{}, false
+		}
+
+		for _, t := range it.candidates(start) {
+			if t.After(it.start) || (it.matchStart && t.Equal(it.start)) {
+				it.pending = append(it.pending, t)
+			}
+		}
+	}
+
+	t := it.pending[0]
+	it.pending = it.pending[1:]
+
+	if (r.Count > 0 && it.count == r.Count) || (!r.Until.IsZero() && t.After(r.Until)) {
+		it.pending = nil
+		it.done = true
+		return time.Time{}, false
+	}
+
+	it.count++
+	return t, true
+}
+
+// periodStart returns the beginning of the i-th period of the rule
+func (it *recurrenceIterator) periodStart(i int) time.Time {
+	s := it.start
+	n := i * it.r.Interval
+
+	switch it.r.Frequency {
+	case FrequencyYearly:
+		return time.Date(s.Year()+n, time.January, 1, 0, 0, 0, 0, s.Location())
+	case FrequencyMonthly:
+		return time.Date(s.Year(), s.Month()+time.Month(n), 1, 0, 0, 0, 0, s.Location())
+	case FrequencyWeekly:
+		offset := (int(s.Weekday()) - int(it.r.WeekStart) + 7) % 7
+		return time.Date(s.Year(), s.Month(), s.Day()-offset+7*n, 0, 0, 0, 0, s.Location())
+	case FrequencyDaily:
+		return time.Date(s.Year(), s.Month(), s.Day()+n, 0, 0, 0, 0, s.Location())
+	case FrequencyHourly:
+		return s.Add(time.Duration(n) * time.Hour)
+	case FrequencyMinutely:
+		return s.Add(time.Duration(n) * time.Minute)
+	default:
+		return s.Add(time.Duration(n) * time.Second)
+	}
+}
+
+// candidates returns the sorted instances of the period starting at start
+func (it *recurrenceIterator) candidates(start time.Time) []time.Time {
+	r := it.r
+	candidates := make([]time.Time, 0)
+
+	switch r.Frequency {
+	case FrequencyHourly, FrequencyMinutely, FrequencySecondly:
+		if r.matchesDay(start) && matchesInt(r.ByHour, start.Hour()) && matchesInt(r.ByMinute, start.Minute()) && matchesInt(r.BySecond, start.Second()) {
+			candidates = append(candidates, start)
+		}
+	default:
+		hours := orDefault(r.ByHour, it.start.Hour())
+		minutes := orDefault(r.ByMinute, it.start.Minute())
+		seconds := orDefault(r.BySecond, it.start.Second())
+
+		for _, day := range it.days(start) {
+			for _, h := range hours {
+				for _, m := range minutes {
+					for _, s := range seconds {
+						candidates = append(candidates, time.Date(day.Year(), day.Month(), day.Day(), h, m, s, 0, day.Location()))
+					}
+				}
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Before(candidates[j])
+	})
+
+	return r.setPositions(candidates)
+}
+
+// days returns the days of the period starting at start which match the
+// rule
+func (it *recurrenceIterator) days(start time.Time) []time.Time {
+	r := it.r
+	days := make([]time.Time, 0)
+
+	switch r.Frequency {
+	case FrequencyYearly:
+		if len(r.ByDay) > 0 && len(r.ByMonth) == 0 && len(r.ByMonthDay) == 0 {
+			return weekdaysIn(start, start.AddDate(1, 0, 0), r.ByDay)
+		}
+
+		// BYMONTHDAY expands to every month, the month of the first
+		// instance is only kept without it
+		months := orDefault(r.ByMonth, int(it.start.Month()))
+
+		if len(r.ByMonth) == 0 && len(r.ByMonthDay) > 0 {
+			months = []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+		}
+
+		for _, month := range months {
+			days = append(days, it.monthDays(time.Date(start.Year(), time.Month(month), 1, 0, 0, 0, 0, start.Location()))...)
+		}
+	case FrequencyMonthly:
+		if matchesInt(r.ByMonth, int(start.Month())) {
+			days = it.monthDays(start)
+		}
+	case FrequencyWeekly:
+		for i := 0; i < 7; i++ {
+			day := time.Date(start.Year(), start.Month(), start.Day()+i, 0, 0, 0, 0, start.Location())
+
+			if !matchesInt(r.ByMonth, int(day.Month())) {
+				continue
+			}
+
+			if (len(r.ByDay) == 0 && day.Weekday() == it.start.Weekday()) || (len(r.ByDay) > 0 && r.matchesDay(day)) {
+				days = append(days, day)
+			}
+		}
+	case FrequencyDaily:
+		if r.matchesDay(start) {
+			days = append(days, start)
+		}
+	}
+
+	return days
+}
+
+// monthDays returns the days of the month starting at start which match
+// the BYMONTHDAY and BYDAY rule parts, or the day of the month of the first
+// instance without them
+func (it *recurrenceIterator) monthDays(start time.Time) []time.Time {
+	r := it.r
+	end := start.AddDate(0, 1, 0)
+	length := end.AddDate(0, 0, -1).Day()
+	days := make([]time.Time, 0)
+
+	switch {
+	case len(r.ByMonthDay) > 0:
+		for _, monthDay := range r.ByMonthDay {
+			if monthDay < 0 {
+				monthDay = length + monthDay + 1
+			}
+
+			day := time.Date(start.Year(), start.Month(), monthDay, 0, 0, 0, 0, start.Location())
+
+			if monthDay >= 1 && monthDay <= length && (len(r.ByDay) == 0 || r.matchesWeekday(day)) {
+				days = append(days, day)
+			}
+		}
+	case len(r.ByDay) > 0:
+		days = weekdaysIn(start, end, r.ByDay)
+	case it.start.Day() <= length:
+		// months without the day of the first instance are skipped
+		days = append(days, time.Date(start.Year(), start.Month(), it.start.Day(), 0, 0, 0, 0, start.Location()))
+	}
+
+	return days
+}
+
+// weekdaysIn returns the days from from to to matching byDay, the ordinals
+// being relative to this range
+func weekdaysIn(from, to time.Time, byDay []WeekdayNum) []time.Time {
+	days := make([]time.Time, 0)
+
+	for _, weekday := range byDay {
+		matching := make([]time.Time, 0)
+
+		for day := from; day.Before(to); day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, day.Location()) {
+			if day.Weekday() == weekday.Weekday {
+				matching = append(matching, day)
+			}
+		}
+
+		switch {
+		case weekday.Ordinal == 0:
+			days = append(days, matching...)
+		case weekday.Ordinal > 0 && weekday.Ordinal <= len(matching):
+			days = append(days, matching[weekday.Ordinal-1])
+		case weekday.Ordinal < 0 && -weekday.Ordinal <= len(matching):
+			days = append(days, matching[len(matching)+weekday.Ordinal])
+		}
+	}
+
+	return days
+}
+
+// matchesDay checks the day matches the BYMONTH, BYMONTHDAY and BYDAY
+// rule parts, used as filters
+func (r *Recurrence) matchesDay(day time.Time) bool {
+	if !matchesInt(r.ByMonth, int(day.Month())) {
+		return false
+	}
+
+	if len(r.ByMonthDay) > 0 {
+		length := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+		found := false
+
+		for _, monthDay := range r.ByMonthDay {
+			if monthDay == day.Day() || length+monthDay+1 == day.Day() {
+				found = true
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return len(r.ByDay) == 0 || r.matchesWeekday(day)
+}
+
+// matchesWeekday checks the day is one of the BYDAY weekdays, regardless
+// of their ordinal
+func (r *Recurrence) matchesWeekday(day time.Time) bool {
+	for _, weekday := range r.ByDay {
+		if weekday.Weekday == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// setPositions keeps the candidates at the BYSETPOS positions, if any
+func (r *Recurrence) setPositions(candidates []time.Time) []time.Time {
+	if len(r.BySetPos) == 0 {
+		return candidates
+	}
+
+	kept := make([]time.Time, 0, len(r.BySetPos))
+
+	for i, t := range candidates {
+		for _, pos := range r.BySetPos {
+			if pos == i+1 || pos == i-len(candidates) {
+				kept = append(kept, t)
+				break
+			}
+		}
+	}
+
+	return kept
+}
+
+// matchesInt checks n is one of values, any n matches an empty list
+func matchesInt(values []int, n int) bool {
+	if len(values) == 0 {
+		return true
+	}
+
+	for _, v := range values {
+		if v == n {
+			return true
+		}
+	}
+
+	return false
+}
+
+// orDefault returns values, or a list of n when it is empty
+func orDefault(values []int, n int) []int {
+	if len(values) == 0 {
+		return []int{n}
+	}
+	return values
+}
@@ -0,0 +1,430 @@
+package ical
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    *Recurrence
+		wantErr bool
+	}{
+		{
+			value: "FREQ=DAILY;COUNT=10",
+			want:  &Recurrence{Frequency: FrequencyDaily, Count: 10, Interval: 1, WeekStart: time.Monday},
+		},
+		{
+			value: "FREQ=MONTHLY;INTERVAL=2;BYDAY=1FR,-1SU;BYMONTHDAY=-1,15;WKST=SU",
+			want: &Recurrence{
+				Frequency:  FrequencyMonthly,
+				Interval:   2,
+				ByDay:      []WeekdayNum{{1, time.Friday}, {-1, time.Sunday}},
+				ByMonthDay: []int{-1, 15},
+				WeekStart:  time.Sunday,
+			},
+		},
+		{
+			value: "FREQ=YEARLY;UNTIL=19971224T000000Z",
+			want:  &Recurrence{Frequency: FrequencyYearly, Until: time.Date(1997, time.December, 24, 0, 0, 0, 0, time.UTC), Interval: 1, WeekStart: time.Monday},
+		},
+		{value: "COUNT=10", wantErr: true},
+		{value: "FREQ=FORTNIGHTLY", wantErr: true},
+		{value: "FREQ=DAILY;COUNT=0", wantErr: true},
+		{value: "FREQ=DAILY;COUNT=2;UNTIL=19971224T000000Z", wantErr: true},
+		{value: "FREQ=DAILY;BYMONTH=13", wantErr: true},
+		{value: "FREQ=DAILY;BYMONTHDAY=0", wantErr: true},
+		{value: "FREQ=WEEKLY;BYDAY=XX", wantErr: true},
+		{value: "FREQ=YEARLY;BYWEEKNO=20", wantErr: true},
+		{value: "FREQ=DAILY;COUNT", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseRecurrence(tt.value, time.UTC)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseRecurrence() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Frequency != tt.want.Frequency || !got.Until.Equal(tt.want.Until) || got.Count != tt.want.Count || got.Interval != tt.want.Interval || got.WeekStart != tt.want.WeekStart {
+				t.Errorf("ParseRecurrence() = %+v, want %+v", got, tt.want)
+			}
+			if len(got.ByDay) != len(tt.want.ByDay) || len(got.ByMonthDay) != len(tt.want.ByMonthDay) {
+				t.Fatalf("ParseRecurrence() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got.ByDay {
+				if got.ByDay[i] != tt.want.ByDay[i] {
+					t.Errorf("ParseRecurrence() ByDay = %v, want %v", got.ByDay, tt.want.ByDay)
+				}
+			}
+			for i := range got.ByMonthDay {
+				if got.ByMonthDay[i] != tt.want.ByMonthDay[i] {
+					t.Errorf("ParseRecurrence() ByMonthDay = %v, want %v", got.ByMonthDay, tt.want.ByMonthDay)
+				}
+			}
+		})
+	}
+}
+
+func TestEvent_Expand(t *testing.T) {
+	from := time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2010, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		start      time.Time
+		properties []*Property
+		from, to   time.Time
+		want       []string
+	}{
+		{
+			name:       "daily count",
+			start:      time.Date(1997, time.September, 2, 9, 0, 0, 0, time.UTC),
+			properties: []*Property{{Name: "RRULE", Value: "FREQ=DAILY;COUNT=4"}},
+			want:       []string{"19970902T090000Z", "19970903T090000Z", "19970904T090000Z", "19970905T090000Z"},
+		},
+		{
+			name:       "daily until date",
+			start:      time.Date(1997, time.September, 2, 9, 0, 0, 0, time.UTC),
+			properties: []*Property{{Name: "RRULE", Value: "FREQ=DAILY;UNTIL=19970904"}},
+			want:       []string{"19970902T090000Z", "19970903T090000Z", "19970904T090000Z"},
+		},
+		{
+			name:       "every other week on tuesday and thursday",
+			start:      time.Date(1997, time.September, 2, 9, 0, 0, 0, time.UTC),
+			properties: []*Property{{Name: "RRULE", Value: "FREQ=WEEKLY;INTERVAL=2;WKST=SU;BYDAY=TU,TH;COUNT=8"}},
+			want:       []string{"19970902T090000Z", "19970904T090000Z", "19970916T090000Z", "19970918T090000Z", "19970930T090000Z", "19971002T090000Z", "19971014T090000Z", "19971016T090000Z"},
+		},
+		{
+			name:       "monthly on the first friday",
+			start:      time.Date(1997, time.September, 5, 9, 0, 0, 0, time.UTC),
+			properties: []*Property{{Name: "RRULE", Value: "FREQ=MONTHLY;COUNT=4;BYDAY=1FR"}},
+			want:       []string{"19970905T090000Z", "19971003T090000Z", "19971107T090000Z", "19971205T090000Z"},
+		},
+		{
+			name:       "monthly on the last day",
+			start:      time.Date(1997, time.September, 30, 9, 0, 0, 0, time.UTC),
+			properties: []*Property{{Name: "RRULE", Value: "FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=3"}},
+			want:       []string{"19970930T090000Z", "19971031T090000Z", "19971130T090000Z"},
+		},
+		{
+			name:       "monthly on the last work day",
+			start:      time.Date(1997, time.September, 30, 9, 0, 0, 0, time.UTC),
+			properties: []*Property{{Name: "RRULE", Value: "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1;COUNT=3"}},
+			want:       []string{"19970930T090000Z", "19971031T090000Z", "19971128T090000Z"},
+		},
+		{
+			name:       "monthly skipping short months",
+			start:      time.Date(2007, time.January, 31, 9, 0, 0, 0, time.UTC),
+			properties: []*Property{{Name: "RRULE", Value: "FREQ=MONTHLY;COUNT=3"}},
+			want:       []string{"20070131T090000Z", "20070331T090000Z", "20070531T090000Z"},
+		},
+		{
+			name:       "yearly in june and july",
+			start:      time.Date(1997, time.June, 10, 9, 0, 0, 0, time.UTC),
+			properties: []*Property{{Name: "RRULE", Value: "FREQ=YEARLY;COUNT=4;BYMONTH=6,7"}},
+			want:       []string{"19970610T090000Z", "19970710T090000Z", "19980610T090000Z", "19980710T090000Z"},
+		},
+		{
+			name:       "yearly on the first day of every month",
+			start:      time.Date(2020, time.January, 15, 9, 0, 0, 0, time.UTC),
+			properties: []*Property{{Name: "RRULE", Value: "FREQ=YEARLY;BYMONTHDAY=1;COUNT=4"}},
+			from:       time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+			to:         time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC),
+			want:       []string{"20200115T090000Z", "20200201T090000Z", "20200301T090000Z", "20200401T090000Z"},
+		},
+		{
+			name:  "rdate and exdate",
+			start: time.Date(1997, time.September, 2, 9, 0, 0, 0, time.UTC),
+			properties: []*Property{
+				{Name: "RRULE", Value: "FREQ=DAILY;COUNT=3"},
+				{Name: "EXDATE", Value: "19970903T090000Z"},
+				{Name: "RDATE", Value: "19970910T090000Z,19970902T090000Z"},
+			},
+			want: []string{"19970902T090000Z", "19970904T090000Z", "19970910T090000Z"},
+		},
+		{
+			name:       "window",
+			start:      time.Date(1997, time.September, 2, 9, 0, 0, 0, time.UTC),
+			properties: []*Property{{Name: "RRULE", Value: "FREQ=DAILY"}},
+			from:       time.Date(1997, time.September, 5, 9, 30, 0, 0, time.UTC),
+			to:         time.Date(1997, time.September, 7, 9, 0, 0, 0, time.UTC),
+			want:       []string{"19970905T090000Z", "19970906T090000Z"},
+		},
+		{
+			name:  "not recurring",
+			start: time.Date(1997, time.September, 2, 9, 0, 0, 0, time.UTC),
+			want:  []string{"19970902T090000Z"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &Event{StartDate: tt.start, EndDate: tt.start.Add(time.Hour), Properties: tt.properties}
+			windowFrom, windowTo := tt.from, tt.to
+			if windowFrom.IsZero() {
+				windowFrom, windowTo = from, to
+			}
+			got, err := v.Expand(windowFrom, windowTo, 0)
+			if err != nil {
+				t.Fatalf("Expand() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expand() = %v, want %v", got, tt.want)
+			}
+			for i, start := range got {
+				if start.Format(dateTimeLayoutUTC) != tt.want[i] {
+					t.Errorf("Expand()[%d] = %s, want %s", i, start.Format(dateTimeLayoutUTC), tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestEvent_Expand_untilValueType(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	// the last day would be lost comparing its midnight in New York to
+	// the UTC midnight of UNTIL
+	start := time.Date(1998, time.April, 15, 0, 0, 0, 0, loc)
+	v := &Event{
+		StartDate:  start,
+		EndDate:    start.AddDate(0, 0, 1),
+		AllDay:     true,
+		Properties: []*Property{{Name: "RRULE", Value: "FREQ=DAILY;UNTIL=19980417T000000Z"}},
+	}
+
+	got, err := v.Expand(start, start.AddDate(1, 0, 0), 0)
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+
+	if len(got) != 3 {
+		t.Errorf("Expand() = %v, want 3 instances", got)
+	}
+}
+
+func TestEvent_Expand_zonedDates(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	// the event is in UTC, the TZID applies to every entry of the lists
+	start := time.Date(1997, time.September, 2, 9, 0, 0, 0, time.UTC)
+	zoned := map[string]*Param{"TZID": {Values: []string{"Europe/Paris"}}}
+	v := &Event{
+		StartDate: start,
+		EndDate:   start.Add(time.Hour),
+		Properties: []*Property{
+			{Name: "RRULE", Value: "FREQ=DAILY;COUNT=5"},
+			{Name: "EXDATE", Params: zoned, Value: "19970903T110000,19970905T110000"},
+			{Name: "RDATE", Params: zoned, Value: "19970910T110000,19970911T110000/PT1H"},
+		},
+	}
+
+	got, err := v.Expand(start, start.AddDate(0, 1, 0), 0)
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+
+	want := []time.Time{
+		start,
+		start.AddDate(0, 0, 2),
+		start.AddDate(0, 0, 4),
+		time.Date(1997, time.September, 10, 11, 0, 0, 0, paris),
+		time.Date(1997, time.September, 11, 11, 0, 0, 0, paris),
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("Expand() = %v, want %v", got, want)
+	}
+
+	for i := range got {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("Expand()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestEvent_Expand_maxInstances(t *testing.T) {
+	start := time.Date(2000, time.January, 1, 9, 0, 0, 0, time.UTC)
+	v := &Event{
+		StartDate:  start,
+		EndDate:    start.Add(time.Hour),
+		Properties: []*Property{{Name: "RRULE", Value: "FREQ=YEARLY"}},
+	}
+
+	_, err := v.Expand(start, start.AddDate(1000, 0, 0), 100)
+
+	if !errors.Is(err, ErrTooManyInstances) {
+		t.Errorf("Expand() error = %v, want %v", err, ErrTooManyInstances)
+	}
+
+	got, err := v.Expand(start, start.AddDate(100, 0, 0), 100)
+
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+
+	if len(got) != 100 {
+		t.Errorf("Expand() returned %d instances, want 100", len(got))
+	}
+}
+
+func TestEvent_Instances(t *testing.T) {
+	start := time.Date(1997, time.September, 2, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		properties []*Property
+		n          int
+		want       []time.Time
+	}{
+		{
+			name:       "weekly",
+			properties: []*Property{{Name: "RRULE", Value: "FREQ=WEEKLY"}},
+			n:          3,
+			want:       []time.Time{start, start.AddDate(0, 0, 7), start.AddDate(0, 0, 14)},
+		},
+		{
+			name: "rdate and exdate",
+			properties: []*Property{
+				{Name: "RRULE", Value: "FREQ=WEEKLY"},
+				{Name: "EXDATE", Value: "19970909T090000Z"},
+				{Name: "RDATE", Value: "19970903T090000Z"},
+			},
+			n:    3,
+			want: []time.Time{start, start.AddDate(0, 0, 1), start.AddDate(0, 0, 14)},
+		},
+		{
+			name:       "count",
+			properties: []*Property{{Name: "RRULE", Value: "FREQ=DAILY;COUNT=2"}},
+			n:          5,
+			want:       []time.Time{start, start.AddDate(0, 0, 1)},
+		},
+		{
+			name: "not recurring",
+			n:    5,
+			want: []time.Time{start},
+		},
+		{
+			name:       "none",
+			properties: []*Property{{Name: "RRULE", Value: "FREQ=DAILY"}},
+			n:          0,
+			want:       []time.Time{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &Event{UID: "uid@example.com", StartDate: start, EndDate: start.Add(time.Hour), Properties: tt.properties}
+
+			got := v.Instances(tt.n)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("Instances() returned %d instances, want %d", len(got), len(tt.want))
+			}
+
+			for i, instance := range got {
+				if !instance.StartDate.Equal(tt.want[i]) || !instance.EndDate.Equal(tt.want[i].Add(time.Hour)) || instance.UID != v.UID {
+					t.Errorf("Instances()[%d] = %v - %v, want %v - %v", i, instance.StartDate, instance.EndDate, tt.want[i], tt.want[i].Add(time.Hour))
+				}
+			}
+
+			if len(got) > 0 && got[0] == v {
+				t.Error("Instances() returned the event itself, want a copy")
+			}
+		})
+	}
+}
+
+func TestEvent_Expand_exrule(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:19970901T090000Z\r\nUID:uid@example.com\r\n" +
+		"DTSTART:19970902T090000Z\r\nDTEND:19970902T100000Z\r\nRRULE:FREQ=DAILY;COUNT=10\r\n" +
+		"EXRULE:FREQ=WEEKLY;BYDAY=SA,SU\r\nRDATE:19970914T090000Z,19970916T090000Z\r\nEXRULE:FREQ=MONTHLY\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := c.Events[0]
+
+	if len(v.ExRules) != 2 || v.ExRules[0].Frequency != FrequencyWeekly {
+		t.Fatalf("ExRules = %+v, want the weekly and monthly rules", v.ExRules)
+	}
+
+	// the monthly rule excludes the DTSTART, which it matches
+	want := []string{"19970903T090000Z", "19970904T090000Z", "19970905T090000Z", "19970908T090000Z",
+		"19970909T090000Z", "19970910T090000Z", "19970911T090000Z", "19970916T090000Z"}
+
+	starts, err := v.Expand(time.Date(1997, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(1998, time.January, 1, 0, 0, 0, 0, time.UTC), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make([]string, 0, len(starts))
+	for _, start := range starts {
+		got = append(got, start.Format(dateTimeLayoutUTC))
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expand() = %v, want %v", got, want)
+	}
+
+	instances := v.Instances(3)
+	got = got[:0]
+	for _, instance := range instances {
+		got = append(got, instance.StartDate.Format(dateTimeLayoutUTC))
+	}
+
+	if !reflect.DeepEqual(got, want[:3]) {
+		t.Errorf("Instances() = %v, want %v", got, want[:3])
+	}
+
+	// a DTSTART which doesn't match the rule is kept
+	v.ExRules = v.ExRules[:1]
+
+	if instances := v.Instances(1); !instances[0].StartDate.Equal(v.StartDate) {
+		t.Errorf("Instances() = %v, want the DTSTART", instances[0].StartDate)
+	}
+
+	var buf bytes.Buffer
+	if err := Format(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "EXRULE:FREQ=WEEKLY;BYDAY=SA,SU\r\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("Format() = %q, want it to contain %q", buf.String(), want)
+	}
+}
+
+func TestParse_invalidExrule(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:test\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:19970901T090000Z\r\nUID:uid@example.com\r\n" +
+		"DTSTART:19970902T090000Z\r\nEXRULE:FREQ=FORTNIGHTLY\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.Events[0].ExRules) != 0 || len(c.Warnings) != 1 {
+		t.Errorf("ExRules = %v, Warnings = %v, want the rule skipped with a warning", c.Events[0].ExRules, c.Warnings)
+	}
+
+	if _, err := Parse(strings.NewReader(ics), nil, WithStrict(true)); err == nil {
+		t.Error("Parse() error = nil, want an error in strict mode")
+	}
+}
@@ -0,0 +1,65 @@
+package ical
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ParseUTCOffset transforms an iCalendar UTC offset, as found in the
+// TZOFFSETFROM and TZOFFSETTO properties, into seconds east of UTC
+//
+// utc-offset = time-numzone
+// time-numzone = ("+" / "-") time-hour time-minute [time-second]
+func ParseUTCOffset(s string) (int, error) {
+	if (len(s) != 5 && len(s) != 7) || (s[0] != '+' && s[0] != '-') || !isDigits(s[1:]) {
+		return 0, fmt.Errorf("invalid utc offset %q, expected (+/-)HHMM[SS]", s)
+	}
+
+	hours, _ := strconv.Atoi(s[1:3])
+	minutes, _ := strconv.Atoi(s[3:5])
+	seconds := 0
+
+	if len(s) == 7 {
+		seconds, _ = strconv.Atoi(s[5:7])
+	}
+
+	if hours > 23 || minutes > 59 || seconds > 59 {
+		return 0, fmt.Errorf("invalid utc offset %q, out of range", s)
+	}
+
+	offset := hours*3600 + minutes*60 + seconds
+
+	if s[0] == '-' {
+		// "-0000" and "-000000" are not allowed, UTC is "+0000"
+		if offset == 0 {
+			return 0, fmt.Errorf("invalid utc offset %q, use \"+0000\" for UTC", s)
+		}
+		offset = -offset
+	}
+
+	return offset, nil
+}
+
+// TimezoneIDs returns the TZIDs defined by the VTIMEZONE components of the
+// calendar, in document order
+func (c *Calendar) TimezoneIDs() []string {
+	ids := make([]string, 0, len(c.Timezones))
+
+	for _, z := range c.Timezones {
+		ids = append(ids, z.TZID)
+	}
+
+	return ids
+}
+
+// TimezoneByID returns the VTIMEZONE component defining tzid, nil when the
+// calendar has none
+func (c *Calendar) TimezoneByID(tzid string) *Timezone {
+	for _, z := range c.Timezones {
+		if z.TZID == tzid {
+			return z
+		}
+	}
+
+	return nil
+}
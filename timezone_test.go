@@ -0,0 +1,84 @@
+package ical
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseUTCOffset(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "+0000", want: 0},
+		{value: "-0500", want: -5 * 3600},
+		{value: "+0100", want: 3600},
+		{value: "+0530", want: 5*3600 + 30*60},
+		{value: "-001530", want: -(15*60 + 30)},
+		{value: "+1345", want: 13*3600 + 45*60},
+		{value: "", wantErr: true},
+		{value: "0100", wantErr: true},
+		{value: "+01", wantErr: true},
+		{value: "+01:00", wantErr: true},
+		{value: "+2400", wantErr: true},
+		{value: "+0060", wantErr: true},
+		{value: "-0000", wantErr: true},
+		{value: "-000000", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseUTCOffset(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseUTCOffset() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseUTCOffset() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimezoneRule_offsets(t *testing.T) {
+	file, _ := os.Open("fixtures/vtimezone.ics")
+	c, err := Parse(file, nil)
+	file.Close()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	standard := c.Timezones[0].Standard[0]
+
+	if standard.OffsetFrom != -4*3600 || standard.OffsetTo != -5*3600 {
+		t.Errorf("offsets = %d and %d, want %d and %d", standard.OffsetFrom, standard.OffsetTo, -4*3600, -5*3600)
+	}
+}
+
+func TestCalendar_TimezoneIDs(t *testing.T) {
+	file, _ := os.Open("fixtures/vtimezone.ics")
+	c, err := Parse(file, nil)
+	file.Close()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := c.TimezoneIDs(), []string{"Custom", "Unused"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TimezoneIDs() = %v, want %v", got, want)
+	}
+
+	if z := c.TimezoneByID("Unused"); z != c.Timezones[1] {
+		t.Errorf("TimezoneByID(Unused) = %v, want the second VTIMEZONE", z)
+	}
+
+	if z := c.TimezoneByID("Europe/Paris"); z != nil {
+		t.Errorf("TimezoneByID(Europe/Paris) = %v, want nil", z)
+	}
+
+	if got := NewCalendar().TimezoneIDs(); len(got) != 0 {
+		t.Errorf("TimezoneIDs() = %v, want none", got)
+	}
+}
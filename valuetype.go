@@ -0,0 +1,109 @@
+package ical
+
+import (
+	"strings"
+	"sync"
+)
+
+// A ValueType identifies the format of a property value
+type ValueType string
+
+// Value types defined by RFC 5545
+const (
+	ValueBinary     ValueType = "BINARY"
+	ValueBoolean    ValueType = "BOOLEAN"
+	ValueCalAddress ValueType = "CAL-ADDRESS"
+	ValueDate       ValueType = "DATE"
+	ValueDateTime   ValueType = "DATE-TIME"
+	ValueDuration   ValueType = "DURATION"
+	ValueFloat      ValueType = "FLOAT"
+	ValueInteger    ValueType = "INTEGER"
+	ValuePeriod     ValueType = "PERIOD"
+	ValueRecur      ValueType = "RECUR"
+	ValueText       ValueType = "TEXT"
+	ValueTime       ValueType = "TIME"
+	ValueURI        ValueType = "URI"
+	ValueUTCOffset  ValueType = "UTC-OFFSET"
+)
+
+// valueTypes maps a property name to its default value type
+var valueTypes = struct {
+	sync.RWMutex
+	m map[string]ValueType
+}{m: map[string]ValueType{
+	"ACTION":           ValueText,
+	"ATTACH":           ValueURI,
+	"ATTENDEE":         ValueCalAddress,
+	"CALSCALE":         ValueText,
+	"CATEGORIES":       ValueText,
+	"CLASS":            ValueText,
+	"COLOR":            ValueText,
+	"COMMENT":          ValueText,
+	"COMPLETED":        ValueDateTime,
+	"CONFERENCE":       ValueURI,
+	"CONTACT":          ValueText,
+	"CREATED":          ValueDateTime,
+	"DESCRIPTION":      ValueText,
+	"DTEND":            ValueDateTime,
+	"DTSTAMP":          ValueDateTime,
+	"DTSTART":          ValueDateTime,
+	"DUE":              ValueDateTime,
+	"DURATION":         ValueDuration,
+	"EXDATE":           ValueDateTime,
+	"EXRULE":           ValueRecur,
+	"FREEBUSY":         ValuePeriod,
+	"GEO":              ValueFloat,
+	"IMAGE":            ValueURI,
+	"LAST-MODIFIED":    ValueDateTime,
+	"LOCATION":         ValueText,
+	"METHOD":           ValueText,
+	"NAME":             ValueText,
+	"ORGANIZER":        ValueCalAddress,
+	"PERCENT-COMPLETE": ValueInteger,
+	"PRIORITY":         ValueInteger,
+	"PRODID":           ValueText,
+	"RDATE":            ValueDateTime,
+	"RECURRENCE-ID":    ValueDateTime,
+	"REFRESH-INTERVAL": ValueDuration,
+	"RELATED-TO":       ValueText,
+	"REPEAT":           ValueInteger,
+	"REQUEST-STATUS":   ValueText,
+	"RESOURCES":        ValueText,
+	"RRULE":            ValueRecur,
+	"SEQUENCE":         ValueInteger,
+	"SOURCE":           ValueURI,
+	"STATUS":           ValueText,
+	"SUMMARY":          ValueText,
+	"TRANSP":           ValueText,
+	"TRIGGER":          ValueDuration,
+	"TZID":             ValueText,
+	"TZNAME":           ValueText,
+	"TZOFFSETFROM":     ValueUTCOffset,
+	"TZOFFSETTO":       ValueUTCOffset,
+	"TZURL":            ValueURI,
+	"UID":              ValueText,
+	"URL":              ValueURI,
+	"VERSION":          ValueText,
+}}
+
+// RegisterProperty sets the default value type of a property, either to
+// declare a custom property or to override a standard one.
+// It is safe for concurrent use.
+func RegisterProperty(name string, valueType ValueType) {
+	valueTypes.Lock()
+	defer valueTypes.Unlock()
+	valueTypes.m[strings.ToUpper(name)] = valueType
+}
+
+// defaultValueType returns the registered value type of a property,
+// unknown and experimental properties default to TEXT
+func defaultValueType(name string) ValueType {
+	valueTypes.RLock()
+	defer valueTypes.RUnlock()
+
+	if valueType, ok := valueTypes.m[strings.ToUpper(name)]; ok {
+		return valueType
+	}
+
+	return ValueText
+}
@@ -0,0 +1,53 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_windowsZones(t *testing.T) {
+	for name, iana := range windowsZones {
+		if _, err := time.LoadLocation(iana); err != nil {
+			t.Errorf("windowsZones[%q] = %s: %v", name, iana, err)
+		}
+	}
+}
+
+func TestParse_windowsTZID(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:-//Microsoft Corporation//Outlook 16.0 MIMEDIR//EN\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:uid@example.com\r\n" +
+		"DTSTART;TZID=W. Europe Standard Time:20200211T100000\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil, WithStrict(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := c.Events[0].StartDate, time.Date(2020, time.February, 11, 9, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("StartDate = %v, want %v", got, want)
+	}
+
+	if got := c.Events[0].StartDate.Location().String(); got != "Europe/Berlin" {
+		t.Errorf("StartDate location = %s, want Europe/Berlin", got)
+	}
+}
+
+func TestParse_windowsTZIDDisabled(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\nPRODID:-//Microsoft Corporation//Outlook 16.0 MIMEDIR//EN\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTAMP:20200211T090000Z\r\nUID:uid@example.com\r\n" +
+		"DTSTART;TZID=W. Europe Standard Time:20200211T100000\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	c, err := Parse(strings.NewReader(ics), nil, WithWindowsTimezones(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := c.Events[0].StartDate, time.Date(2020, time.February, 11, 10, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("StartDate = %v, want %v", got, want)
+	}
+
+	if len(c.Warnings) != 1 {
+		t.Errorf("Warnings = %v, want the unknown TZID", c.Warnings)
+	}
+}